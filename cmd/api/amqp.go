@@ -1,18 +1,18 @@
 package main
 
 import (
-	"log"
+	"log/slog"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 func (app *application) connectToAmqp() (*amqp.Connection, error) {
-	conn, err := amqp.Dial(app.amqpURL)
+	conn, err := amqp.Dial(app.config.AmqpURL.Value())
 	if err != nil {
-		log.Printf("connect RabbitMQ failed: %v", err)
+		slog.Error("[amqp] connect RabbitMQ failed", "err", err)
 		return nil, err
 	}
 
-	log.Println("Connected to RabbitMQ!")
+	slog.Info("[amqp] connected to RabbitMQ")
 	return conn, nil
 }
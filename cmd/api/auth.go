@@ -3,8 +3,9 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +22,8 @@ type Auth struct {
 	CookieDomain  string
 	CookiePath    string
 	CookieName    string
+	CookieSecure  bool
+	CookieSame    http.SameSite
 	RDB           *redis.Client
 }
 
@@ -39,7 +42,7 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func (j *Auth) GenerateTokenPair(user *jwtUser) (TokenPairs, error) {
+func (j *Auth) GenerateTokenPair(ctx context.Context, user *jwtUser) (TokenPairs, error) {
 	// Create a token
 	token := jwt.New(jwt.SigningMethodHS256)
 	accessJTI := fmt.Sprintf("acc-%d-%d", user.ID, time.Now().UnixNano())
@@ -81,7 +84,6 @@ func (j *Auth) GenerateTokenPair(user *jwtUser) (TokenPairs, error) {
 	}
 
 	// 寫入 Redis（allowlist）
-	ctx := context.Background()
 	if j.RDB != nil {
 		// 只存 JTI 即可，值可放 userID 或 "ok"
 		if err := j.RDB.Set(ctx, "access:"+accessJTI, user.ID, j.TokenExpiry).Err(); err != nil {
@@ -91,7 +93,7 @@ func (j *Auth) GenerateTokenPair(user *jwtUser) (TokenPairs, error) {
 			_ = j.RDB.Del(ctx, "access:"+accessJTI).Err()
 			return TokenPairs{}, fmt.Errorf("redis set refresh jti: %w", err)
 		}
-		log.Printf("stored jti in redis: %s", claims["jti"])
+		slog.DebugContext(ctx, "[auth] stored jti in redis", "jti", claims["jti"])
 	}
 
 	// Create TokenPairs and populate with signed tokens
@@ -111,10 +113,10 @@ func (j *Auth) GetRefreshCookie(refreshToken string) *http.Cookie {
 		Value:    refreshToken,
 		Expires:  time.Now().Add(j.RefreshExpiry),
 		MaxAge:   int(j.RefreshExpiry.Seconds()),
-		SameSite: http.SameSiteStrictMode,
+		SameSite: j.CookieSame,
 		Domain:   j.CookieDomain,
 		HttpOnly: true,
-		Secure:   true,
+		Secure:   j.CookieSecure,
 	}
 }
 
@@ -125,35 +127,72 @@ func (j *Auth) GetExpiredRefreshCookie() *http.Cookie {
 		Value:    "",
 		Expires:  time.Unix(0, 0),
 		MaxAge:   -1,
-		SameSite: http.SameSiteStrictMode,
+		SameSite: j.CookieSame,
 		Domain:   j.CookieDomain,
 		HttpOnly: true,
-		Secure:   true,
+		Secure:   j.CookieSecure,
 	}
 }
 
 func (j *Auth) GetTokenFromHeaderAndVerify(w http.ResponseWriter, r *http.Request) (string, *Claims, error) {
 	w.Header().Add("Vary", "Authorization")
 
+	token, claims, err := j.parseToken(r)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Redis 檢查（allowlist & revoke）
+	if j.RDB != nil {
+		ctx := r.Context()
+		jti := claims.ID
+		if jti == "" {
+			return "", nil, fmt.Errorf("missing jti")
+		}
+
+		// 是否被撤銷？
+		revoked, err := j.RDB.Exists(ctx, "revoked:"+jti).Result()
+		if err != nil {
+			return "", nil, fmt.Errorf("redis error: %w", err)
+		}
+		if revoked == 1 {
+			return "", nil, fmt.Errorf("token revoked")
+		}
+
+		// 是否在 allowlist？
+		allowed, err := j.RDB.Exists(ctx, "access:"+jti).Result()
+		if err != nil {
+			return "", nil, fmt.Errorf("redis error: %w", err)
+		}
+		if allowed != 1 {
+			return "", nil, fmt.Errorf("token not in allowlist")
+		}
+	}
+
+	return token, claims, nil
+}
+
+// parseToken 解析 Authorization header 並驗證簽章、issuer 與 audience（不查 Redis）
+func (j *Auth) parseToken(r *http.Request) (string, *Claims, error) {
 	// get auth header
 	authHeader := r.Header.Get("Authorization")
 	//log.Printf("auth header: %s", authHeader)
 	// sanity check
 	if authHeader == "" {
-		log.Print("no auth header")
+		slog.DebugContext(r.Context(), "[auth] no auth header")
 		return "", nil, fmt.Errorf("no auth header")
 	}
 
 	// split the header on spaces
 	headerParts := strings.Split(authHeader, " ")
 	if len(headerParts) != 2 {
-		log.Print("invalid auth header")
+		slog.InfoContext(r.Context(), "[auth] invalid auth header")
 		return "", nil, fmt.Errorf("invalid auth header")
 	}
 
 	// check to see if we have the word Bearer
 	if headerParts[0] != "Bearer" {
-		log.Print("invalid auth header")
+		slog.InfoContext(r.Context(), "[auth] invalid auth header")
 		return "", nil, fmt.Errorf("invalid auth header")
 	}
 
@@ -172,14 +211,14 @@ func (j *Auth) GetTokenFromHeaderAndVerify(w http.ResponseWriter, r *http.Reques
 
 	if err != nil {
 		if strings.HasPrefix(err.Error(), "token is expired by") {
-			log.Print("expired token")
+			slog.InfoContext(r.Context(), "[auth] expired token")
 			return "", nil, fmt.Errorf("expired token")
 		}
 		return "", nil, err
 	}
 
 	if claims.Issuer != j.Issuer {
-		log.Print("invalid issuer")
+		slog.InfoContext(r.Context(), "[auth] invalid issuer")
 		return "", nil, fmt.Errorf("invalid issuer")
 	}
 
@@ -189,42 +228,28 @@ func (j *Auth) GetTokenFromHeaderAndVerify(w http.ResponseWriter, r *http.Reques
 
 	//log.Printf("claims: %+v", claims)
 
-	// Redis 檢查（allowlist & revoke）
-	if j.RDB != nil {
-		ctx := context.Background()
-		jti := claims.ID
-		if jti == "" {
-			return "", nil, fmt.Errorf("missing jti")
-		}
-
-		// 是否被撤銷？
-		revoked, err := j.RDB.Exists(ctx, "revoked:"+jti).Result()
-		if err != nil {
-			return "", nil, fmt.Errorf("redis error: %w", err)
-		}
-		if revoked == 1 {
-			return "", nil, fmt.Errorf("token revoked")
-		}
+	return token, claims, nil
+}
 
-		// 是否在 allowlist？
-		allowed, err := j.RDB.Exists(ctx, "access:"+jti).Result()
-		if err != nil {
-			return "", nil, fmt.Errorf("redis error: %w", err)
-		}
-		if allowed != 1 {
-			return "", nil, fmt.Errorf("token not in allowlist")
-		}
+// UserIDFromRequest 簽章有效的 access token 的 user id（不查撤銷），沒有或無效時回傳 0；
+// 給登入前也會經過的 middleware（例如限流）使用
+func (j *Auth) UserIDFromRequest(r *http.Request) int {
+	_, claims, err := j.parseToken(r)
+	if err != nil {
+		return 0
 	}
-
-	return token, claims, nil
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0
+	}
+	return id
 }
 
 // 登出：撤銷 access token
-func (j *Auth) RevokeAccessToken(jti string, exp time.Time) error {
+func (j *Auth) RevokeAccessToken(ctx context.Context, jti string, exp time.Time) error {
 	if j.RDB == nil {
 		return nil
 	}
-	ctx := context.Background()
 	ttl := time.Until(exp)
 	if ttl <= 0 {
 		ttl = time.Minute // 防呆
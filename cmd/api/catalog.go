@@ -0,0 +1,225 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
+	"github.com/wkchen007/nftweb-back/internal/models"
+	"github.com/wkchen007/nftweb-back/internal/repository"
+)
+
+// catalogOf 由 ?collection=<slug> 取得商品目錄命名空間，未指定時為預設合集
+func (app *application) catalogOf(r *http.Request) (string, error) {
+	slug := r.URL.Query().Get("collection")
+	if slug == "" {
+		return app.collections.Default().Service().Config().Catalog, nil
+	}
+	h, ok := app.collections.Get(slug)
+	if !ok {
+		return "", httpapi.NotFound("collection %s not found", slug)
+	}
+	return h.Service().Config().Catalog, nil
+}
+
+// catalogWritable 使用此商品目錄的任一合集已 commit 分配時回傳 409：
+// 分配依 commit 當下的商品抽出，之後再改商品會讓 mapping 與 commitment 對不上
+func (app *application) catalogWritable(r *http.Request, catalog string) error {
+	for _, h := range app.collections.All() {
+		svc := h.Service()
+		if svc.Config().Catalog != catalog {
+			continue
+		}
+		committed, err := svc.AssignmentCommitted(r.Context())
+		if err != nil {
+			return err
+		}
+		if committed {
+			return httpapi.Conflict("catalog %s is frozen: collection %s has a committed assignment", catalog, svc.Slug())
+		}
+	}
+	return nil
+}
+
+// CatalogItems 回傳合集所有盲盒商品（含盲盒圖與非 demo 項目）
+func (app *application) CatalogItems(w http.ResponseWriter, r *http.Request) {
+	catalog, err := app.catalogOf(r)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+
+	nfts, err := app.DB.CatalogItems(r.Context(), catalog)
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, nfts)
+}
+
+func (app *application) GetCatalogItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid id"))
+		return
+	}
+
+	catalog, err := app.catalogOf(r)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+
+	nft, err := app.DB.GetNFT(r.Context(), catalog, id)
+	if err != nil {
+		app.catalogError(w, r, id, err)
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, nft)
+}
+
+func (app *application) InsertCatalogItem(w http.ResponseWriter, r *http.Request) {
+	catalog, err := app.catalogOf(r)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+	if err := app.catalogWritable(r, catalog); err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+
+	var nft models.NFT
+	err = app.readJSON(w, r, &nft)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+	nft.Collection = catalog
+	if err := nft.Validate(); err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("%s", err))
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+	if err := app.DB.InsertNFT(r.Context(), nft, userID); err != nil {
+		app.catalogError(w, r, nft.ID, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "[catalog] item created", "catalog", catalog, "id", nft.ID, "user_id", userID)
+
+	_ = app.writeJSON(w, http.StatusCreated, nft)
+}
+
+func (app *application) UpdateCatalogItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid id"))
+		return
+	}
+
+	catalog, err := app.catalogOf(r)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+	if err := app.catalogWritable(r, catalog); err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+
+	var nft models.NFT
+	err = app.readJSON(w, r, &nft)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+	// token id 與合集以路徑 / 參數為準，不允許透過更新修改
+	nft.ID = id
+	nft.Collection = catalog
+	if err := nft.Validate(); err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("%s", err))
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+	if err := app.DB.UpdateNFT(r.Context(), nft, userID); err != nil {
+		app.catalogError(w, r, id, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "[catalog] item updated", "catalog", catalog, "id", id, "user_id", userID)
+
+	_ = app.writeJSON(w, http.StatusOK, nft)
+}
+
+func (app *application) DeleteCatalogItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid id"))
+		return
+	}
+
+	catalog, err := app.catalogOf(r)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+	if err := app.catalogWritable(r, catalog); err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+
+	userID := userIDFromContext(r.Context())
+	if err := app.DB.DeleteNFT(r.Context(), catalog, id, userID); err != nil {
+		app.catalogError(w, r, id, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "[catalog] item deleted", "catalog", catalog, "id", id, "user_id", userID)
+
+	_ = app.writeJSON(w, http.StatusOK, JSONResponse{Message: fmt.Sprintf("item %d deleted", id)})
+}
+
+// CatalogAudit 查詢異動紀錄，?nftId= 指定商品
+func (app *application) CatalogAudit(w http.ResponseWriter, r *http.Request) {
+	nftID := -1
+	if v := r.URL.Query().Get("nftId"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			httpapi.WriteError(w, r, httpapi.Invalid("invalid nftId"))
+			return
+		}
+		nftID = id
+	}
+
+	catalog, err := app.catalogOf(r)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+
+	logs, err := app.DB.CatalogAudit(r.Context(), catalog, nftID)
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, logs)
+}
+
+func (app *application) catalogError(w http.ResponseWriter, r *http.Request, id int, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		httpapi.WriteError(w, r, httpapi.NotFound("item %d not found", id))
+	case errors.Is(err, repository.ErrDuplicateID):
+		httpapi.WriteError(w, r, httpapi.Conflict("item %d already exists", id))
+	default:
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+	}
+}
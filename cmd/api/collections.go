@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
+	"github.com/wkchen007/nftweb-back/internal/nft"
+)
+
+// nftHandler 以 method expression 表示的 nft.Handlers 方法，例如 (*nft.Handlers).Owner
+type nftHandler func(*nft.Handlers, http.ResponseWriter, *http.Request)
+
+// collection 依 pick 選出合集後呼叫 fn；找不到合集時回 404
+func (app *application) collection(pick func(*http.Request) (*nft.Handlers, bool), fn nftHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h, ok := pick(r)
+		if !ok {
+			httpapi.WriteError(w, r, httpapi.NotFound("collection %s not found", chi.URLParam(r, "slug")))
+			return
+		}
+		fn(h, w, r)
+	}
+}
+
+// defaultCollection /nft 路由使用預設合集
+func (app *application) defaultCollection(r *http.Request) (*nft.Handlers, bool) {
+	return app.collections.Default(), true
+}
+
+// slugCollection /collections/{slug} 路由依路徑選擇合集
+func (app *application) slugCollection(r *http.Request) (*nft.Handlers, bool) {
+	return app.collections.Get(chi.URLParam(r, "slug"))
+}
+
+type collectionInfo struct {
+	Slug     string `json:"slug"`
+	Contract string `json:"contract"`
+	Catalog  string `json:"catalog"`
+	ChainID  uint64 `json:"chainId"`
+	Network  string `json:"network"`
+}
+
+// Collections 列出所有合集（第一個為預設合集）
+func (app *application) Collections(w http.ResponseWriter, r *http.Request) {
+	list := []collectionInfo{}
+	for _, h := range app.collections.All() {
+		svc := h.Service()
+		list = append(list, collectionInfo{
+			Slug:     svc.Slug(),
+			Contract: svc.Contract().Hex(),
+			Catalog:  svc.Config().Catalog,
+			ChainID:  svc.Chain().ID,
+			Network:  svc.Chain().Name,
+		})
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, list)
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"database/sql"
-	"log"
+	"log/slog"
 
 	_ "github.com/jackc/pgconn"
 	_ "github.com/jackc/pgx/v4"
@@ -24,11 +24,11 @@ func openDB(dsn string) (*sql.DB, error) {
 }
 
 func (app *application) connectToDB() (*sql.DB, error) {
-	connection, err := openDB(app.DSN)
+	connection, err := openDB(app.config.DSN.Value())
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Connected to Postgres!")
+	slog.Info("[db] connected to Postgres")
 	return connection, nil
 }
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
-	"os"
+	"strconv"
+	"time"
 
 	"github.com/wkchen007/nftweb-back/internal/ethcli"
 	"github.com/wkchen007/nftweb-back/internal/event"
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
+	"github.com/wkchen007/nftweb-back/internal/logging"
+	"github.com/wkchen007/nftweb-back/internal/models"
 )
 
 // /healthz handler
@@ -16,7 +24,7 @@ func (app *application) healthzHandler(w http.ResponseWriter, r *http.Request) {
 	//服務本身活著就回200
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprint(w, "ok")
-	log.Printf("[http] health check ok")
+	slog.DebugContext(r.Context(), "[http] health check ok")
 }
 
 func (app *application) Home(w http.ResponseWriter, r *http.Request) {
@@ -42,37 +50,45 @@ func (app *application) authenticate(w http.ResponseWriter, r *http.Request) {
 
 	err := app.readJSON(w, r, &requestPayload)
 	if err != nil {
-		app.errorJSON(w, err, http.StatusBadRequest)
+		httpapi.WriteError(w, r, err)
 		return
 	}
 
-	// validate user against database
-	user, err := app.DB.GetUserByEmail(requestPayload.Email)
-	if err != nil {
-		app.errorJSON(w, fmt.Errorf("invalid credentials"), http.StatusBadRequest)
+	// 延遲或鎖定中的 email / IP 直接拒絕，不跑 bcrypt；Redis 出錯時放行（帳號鎖定仍由 DB 檢查）
+	ip := app.limiter.ClientIP(r.RemoteAddr, r.Header.Get("X-Forwarded-For")).String()
+	if wait, err := app.lockout.Check(r.Context(), requestPayload.Email, ip); err != nil {
+		slog.ErrorContext(r.Context(), "[auth] lockout check failed, allowing attempt", "err", err)
+	} else if wait > 0 {
+		app.tooManyAttempts(w, r, wait)
 		return
 	}
 
-	// check password
-	valid, err := user.PasswordMatches(requestPayload.Password)
-	if err != nil || !valid {
-		app.errorJSON(w, fmt.Errorf("invalid email or password"), http.StatusBadRequest)
+	// validate user against database
+	user, err := app.DB.GetUserByEmail(r.Context(), requestPayload.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
 		return
 	}
 
-	//寫入 eth client 的 signer
-	req := ethcli.UseSignerRequest{
-		PrivateKey: os.Getenv("PRIVATE_KEY"),
+	// 停用或鎖定的帳號不跑 bcrypt；帳號不存在、停用、鎖定或密碼錯誤都回同樣的錯誤並計入失敗次數，避免藉此列舉帳號
+	valid := false
+	switch {
+	case user == nil:
+		models.DummyPasswordCheck(requestPayload.Password)
+	case user.Disabled || user.Locked(time.Now()):
+	default:
+		if valid, err = user.PasswordMatches(requestPayload.Password); err != nil {
+			slog.ErrorContext(r.Context(), "[auth] compare password", "user_id", user.ID, "err", err)
+		}
 	}
-
-	if err := app.ethClient.UseSigner(req); err != nil {
-		app.errorJSON(w, err, http.StatusBadGateway)
+	if !valid {
+		app.loginFailed(r.Context(), requestPayload.Email, ip, user)
+		httpapi.WriteError(w, r, httpapi.New(http.StatusBadRequest, httpapi.CodeInvalidCredentials, "invalid credentials"))
 		return
 	}
 
-	if app.ethClient.From().Hex() != user.WalletAddress {
-		app.errorJSON(w, fmt.Errorf("wallet address not match"), http.StatusBadRequest)
-		return
+	if err := app.lockout.Reset(r.Context(), requestPayload.Email); err != nil {
+		slog.ErrorContext(r.Context(), "[auth] reset login failures", "err", err)
 	}
 
 	// create a jwt user
@@ -83,9 +99,9 @@ func (app *application) authenticate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// generate tokens
-	tokens, err := app.auth.GenerateTokenPair(&u)
+	tokens, err := app.auth.GenerateTokenPair(r.Context(), &u)
 	if err != nil {
-		app.errorJSON(w, err)
+		httpapi.WriteError(w, r, httpapi.Internal(err))
 		return
 	}
 
@@ -100,30 +116,66 @@ func (app *application) authenticate(w http.ResponseWriter, r *http.Request) {
 		Subject: "Login Notification",
 		Message: fmt.Sprintf("User %s logged in", user.Email),
 	}
-	app.pushToQueue("auth", fmt.Sprintf("user %s logged in", user.Email), &mail)
+	app.pushToQueue(r.Context(), "auth", fmt.Sprintf("user %s logged in", user.Email), &mail)
+}
+
+// tooManyAttempts 登入被延遲或鎖定時回 429 與 Retry-After
+func (app *application) tooManyAttempts(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	retry := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+	w.Header().Set("Retry-After", retry)
+	httpapi.WriteError(w, r, httpapi.New(http.StatusTooManyRequests, httpapi.CodeLoginThrottled, "too many failed login attempts, retry after %ss", retry))
+}
+
+// loginFailed 記錄一次登入失敗；email 因此被鎖定時寫入 users.locked_until 並寄信通知
+func (app *application) loginFailed(ctx context.Context, email, ip string, user *models.User) {
+	res, err := app.lockout.Fail(ctx, email, ip)
+	if err != nil {
+		slog.ErrorContext(ctx, "[auth] record login failure", "err", err)
+		return
+	}
+	if !res.Locked || user == nil {
+		return
+	}
+
+	until := time.Now().UTC().Add(app.config.Lockout.LockDuration)
+	if err := app.DB.LockUser(ctx, user.ID, until); err != nil {
+		slog.ErrorContext(ctx, "[auth] lock user", "user_id", user.ID, "err", err)
+	}
+	slog.WarnContext(ctx, "[auth] account locked after repeated login failures", "user_id", user.ID, "ip", ip, "until", until)
+
+	mail := MailPayload{
+		To:      user.Email,
+		Subject: "Account Locked",
+		Message: fmt.Sprintf("Your account %s has been locked until %s after repeated failed login attempts", user.Email, until.Format(time.RFC3339)),
+	}
+	if err := app.pushToQueue(ctx, "auth", fmt.Sprintf("user %s locked until %s", user.Email, until.Format(time.RFC3339)), &mail); err != nil {
+		slog.ErrorContext(ctx, "[auth] push lockout notification", "err", err)
+	}
 }
 
 func (app *application) logout(w http.ResponseWriter, r *http.Request) {
 	// 從 Authorization 取 access，解析拿到 jti 與 exp
 	_, claims, err := app.auth.GetTokenFromHeaderAndVerify(w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
+		httpapi.WriteError(w, r, httpapi.Unauthorized().Wrap(err))
 		return
 	}
 	if claims != nil && claims.ID != "" && claims.ExpiresAt != nil {
-		_ = app.auth.RevokeAccessToken(claims.ID, claims.ExpiresAt.Time)
-		log.Printf("revoked access jti: %s", claims.ID)
+		_ = app.auth.RevokeAccessToken(r.Context(), claims.ID, claims.ExpiresAt.Time)
+		slog.InfoContext(r.Context(), "[auth] revoked access token", "jti", claims.ID)
 	}
 	http.SetCookie(w, app.auth.GetExpiredRefreshCookie())
 	//w.WriteHeader(http.StatusAccepted)
-	w.Header().Set("Location", "http://localhost:3000/login")
+	// 只導向允許清單內的網址，避免 open redirect
+	w.Header().Set("Location", app.config.Policy.Redirects.PostLogoutRedirect(r.URL.Query().Get("redirect")))
 	w.WriteHeader(http.StatusFound)
 }
 
 type LogPayload struct {
-	Name string       `json:"name"`
-	Data string       `json:"data"`
-	Mail *MailPayload `json:"mail,omitempty"`
+	Name      string       `json:"name"`
+	Data      string       `json:"data"`
+	Mail      *MailPayload `json:"mail,omitempty"`
+	RequestID string       `json:"requestId,omitempty"` // 觸發此事件的請求
 }
 
 type MailPayload struct {
@@ -133,15 +185,16 @@ type MailPayload struct {
 }
 
 // pushToQueue pushes a message into RabbitMQ
-func (app *application) pushToQueue(name, msg string, mail *MailPayload) error {
+func (app *application) pushToQueue(ctx context.Context, name, msg string, mail *MailPayload) error {
 	emitter, err := event.NewEventEmitter(app.Amqp)
 	if err != nil {
 		return err
 	}
 
 	payload := LogPayload{
-		Name: name,
-		Data: msg,
+		Name:      name,
+		Data:      msg,
+		RequestID: logging.RequestID(ctx),
 	}
 
 	// 若 mail 不為 nil，則加進 payload
@@ -150,7 +203,7 @@ func (app *application) pushToQueue(name, msg string, mail *MailPayload) error {
 	}
 
 	j, _ := json.MarshalIndent(&payload, "", "\t")
-	err = emitter.Push(string(j), "log.INFO")
+	err = emitter.Push(ctx, string(j), "log.INFO")
 	if err != nil {
 		return err
 	}
@@ -158,25 +211,56 @@ func (app *application) pushToQueue(name, msg string, mail *MailPayload) error {
 }
 
 func (app *application) AllNFTs(w http.ResponseWriter, r *http.Request) {
-	nfts, err := app.DB.AllNFTs()
+	nfts, err := app.DB.AllNFTs(r.Context(), app.collections.Default().Service().Config().Catalog)
 	if err != nil {
-		app.errorJSON(w, err)
+		httpapi.WriteError(w, r, httpapi.Internal(err))
 		return
 	}
 
 	_ = app.writeJSON(w, http.StatusOK, nfts)
 }
 
+// chainOf 由 ?chain=<id 或名稱> 選擇鏈，未指定時為預設鏈
+func (app *application) chainOf(w http.ResponseWriter, r *http.Request) (*ethcli.Client, bool) {
+	c, err := app.chains.Lookup(r.URL.Query().Get("chain"))
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.NotFound("%s", err))
+		return nil, false
+	}
+	return c, true
+}
+
+// Chains 列出已連線的鏈（不含 RPC URL）
+func (app *application) Chains(w http.ResponseWriter, r *http.Request) {
+	chains := []ethcli.Chain{}
+	for _, c := range app.chains.All() {
+		chains = append(chains, c.Chain())
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, chains)
+}
+
 func (app *application) GetWalletAddress(w http.ResponseWriter, r *http.Request) {
-	fromAddr := app.ethClient.GetAddress()
+	ethc, ok := app.chainOf(w, r)
+	if !ok {
+		return
+	}
+	fromAddr := ethc.GetAddress()
 
 	_ = app.writeJSON(w, http.StatusOK, fromAddr)
 }
 
 func (app *application) GetWalletBalance(w http.ResponseWriter, r *http.Request) {
-	wallet, err := app.ethClient.GetBalance()
+	ethc, ok := app.chainOf(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), app.config.Timeouts.Call)
+	defer cancel()
+
+	wallet, err := ethc.GetBalance(ctx)
 	if err != nil {
-		app.errorJSON(w, err, http.StatusBadGateway)
+		httpapi.WriteError(w, r, httpapi.Upstream("get balance failed", err))
 		return
 	}
 
@@ -184,41 +268,106 @@ func (app *application) GetWalletBalance(w http.ResponseWriter, r *http.Request)
 }
 
 func (app *application) PostWalletTransfer(w http.ResponseWriter, r *http.Request) {
+	ethc, ok := app.chainOf(w, r)
+	if !ok {
+		return
+	}
+
 	var req ethcli.TransferRequest
 	err := app.readJSON(w, r, &req)
 	if err != nil {
-		app.errorJSON(w, err, http.StatusBadRequest)
+		httpapi.WriteError(w, r, err)
 		return
 	}
-	log.Printf("[http] transfer request: %+v", req)
+	slog.InfoContext(r.Context(), "[http] transfer request", "req", logging.Redacted(req), "chain", ethc.Network())
 
-	txRes, err := app.ethClient.TransferETH(req)
+	ctx, cancel := context.WithTimeout(r.Context(), app.config.Timeouts.Tx)
+	defer cancel()
+
+	txRes, err := ethc.TransferETH(ctx, req)
 	if err != nil {
-		app.errorJSON(w, err, http.StatusBadGateway)
+		httpapi.WriteError(w, r, httpapi.Upstream("transfer failed", err))
 		return
 	}
 
 	_ = app.writeJSON(w, http.StatusOK, txRes)
+
+	reqID := logging.RequestID(r.Context())
+	app.workers.Go(func(ctx context.Context) {
+		app.notifyTransferConfirmed(logging.WithRequestID(ctx, reqID), ethc, txRes)
+	})
 }
 
+// notifyTransferConfirmed 等待轉帳上鏈後發出 wallet.transfer.confirmed webhook；關閉服務時放棄等待
+func (app *application) notifyTransferConfirmed(ctx context.Context, ethc *ethcli.Client, txRes ethcli.TransferResponse) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	receipt, err := ethc.WaitMined(ctx, txRes.TxHash)
+	if err != nil {
+		slog.ErrorContext(ctx, "[http] wait transfer", "tx", txRes.TxHash, "err", err)
+		return
+	}
+
+	data := struct {
+		ethcli.TransferResponse
+		Status      uint64 `json:"status"` // 1 成功，0 失敗
+		BlockNumber uint64 `json:"blockNumber"`
+	}{
+		TransferResponse: txRes,
+		Status:           receipt.Status,
+		BlockNumber:      receipt.BlockNumber.Uint64(),
+	}
+	src := &models.EventSource{ChainID: ethc.Chain().ID, Block: receipt.BlockNumber.Uint64()}
+	if err := app.webhooks.Publish(ctx, models.EventWalletConfirmed, txRes.TxHash, src, data); err != nil {
+		slog.ErrorContext(ctx, "[http] publish transfer", "tx", txRes.TxHash, "err", err)
+	}
+}
+
+// GetWalletSigners 列出預先註冊的 signer id 與地址
+func (app *application) GetWalletSigners(w http.ResponseWriter, r *http.Request) {
+	active := app.keys.Active()
+	list := []ethcli.SignerInfo{}
+	for _, id := range app.keys.IDs() {
+		s, _ := app.keys.Get(id)
+		list = append(list, ethcli.SignerInfo{KeyID: id, Address: s.Address().Hex(), Active: id == active})
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, list)
+}
+
+// PostWalletUseSigner 以預先註冊的 signer id 切換所有鏈的 signer；不接受私鑰
 func (app *application) PostWalletUseSigner(w http.ResponseWriter, r *http.Request) {
 	var req ethcli.UseSignerRequest
 	err := app.readJSON(w, r, &req)
 	if err != nil {
-		app.errorJSON(w, err, http.StatusBadRequest)
+		httpapi.WriteError(w, r, err)
+		return
+	}
+	if req.KeyID == "" {
+		httpapi.WriteError(w, r, httpapi.Invalid("keyId is required"))
 		return
 	}
-	log.Printf("[http] useSigner request: %+v", req)
 
-	// 切換 signer（會更新 from/chainID/network）
-	if err := app.ethClient.UseSigner(req); err != nil {
-		app.errorJSON(w, err, http.StatusBadGateway)
+	app.signerMu.Lock()
+	signer, err := app.keys.Use(req.KeyID)
+	if err == nil {
+		app.chains.SetSigner(signer)
+	}
+	app.signerMu.Unlock()
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.NotFound("%s", err))
 		return
 	}
+	slog.InfoContext(r.Context(), "[http] signer switched", "keyId", req.KeyID, "address", signer.Address().Hex(), "user", userIDFromContext(r.Context()))
 
 	resp := ethcli.UseSignerResponse{
-		Address: app.ethClient.From().Hex(),
-		Network: app.ethClient.Network(),
+		KeyID:   req.KeyID,
+		Address: signer.Address().Hex(),
+		Chains:  []string{},
+	}
+	for _, c := range app.chains.All() {
+		resp.Chains = append(resp.Chains, c.Network())
 	}
 
 	_ = app.writeJSON(w, http.StatusOK, resp)
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
+	"github.com/wkchen007/nftweb-back/internal/idempotency"
+)
+
+// idempotent 帶 Idempotency-Key 的請求只執行一次：相同 key 與 body 的重試直接回傳保存的回應，
+// key 相同但 body 不同回 422。key 以登入的 user id（否則來源 IP）區分，不同使用者的 key 不會互相影響。
+// 交易送出前的 5xx 不保存，刪除記錄讓用戶端以同一個 key 重試時重新執行；
+// 交易送出後的 5xx（例如 502 / 504，交易可能已上鏈）照樣保存，避免重試時重複送出
+func (app *application) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotency.Header)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > idempotency.MaxKeyLength {
+			httpapi.WriteError(w, r, httpapi.Invalid("%s must be at most %d characters", idempotency.Header, idempotency.MaxKeyLength))
+			return
+		}
+
+		// 讀出 body 計算 fingerprint，再放回給 handler
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1024*1024))
+		if err != nil {
+			httpapi.WriteError(w, r, httpapi.New(http.StatusBadRequest, httpapi.CodeBadRequest, "read request body failed").Wrap(err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := "ip:" + app.limiter.ClientIP(r.RemoteAddr, r.Header.Get("X-Forwarded-For")).String()
+		if id := app.auth.UserIDFromRequest(r); id != 0 {
+			scope = "user:" + strconv.Itoa(id)
+		}
+		storeKey := scope + ":" + key
+		// query 依 key 排序，參數順序不同仍視為同一個請求（例如 ?dryRun=true&collection=x）
+		fingerprint := idempotency.Fingerprint(r.Method, r.URL.Path, r.URL.Query().Encode(), body)
+
+		rec, token, err := app.idempotency.Begin(r.Context(), storeKey, fingerprint)
+		switch {
+		case errors.Is(err, idempotency.ErrMismatch):
+			httpapi.WriteError(w, r, httpapi.New(http.StatusUnprocessableEntity, httpapi.CodeIdempotencyMismatch, "%s", err))
+			return
+		case errors.Is(err, idempotency.ErrInProgress):
+			w.Header().Set("Retry-After", "1")
+			httpapi.WriteError(w, r, httpapi.New(http.StatusConflict, httpapi.CodeIdempotencyInProgress, "%s", err))
+			return
+		case err != nil:
+			// 無法確認是否重複時不執行，避免重複付款
+			httpapi.WriteError(w, r, httpapi.New(http.StatusServiceUnavailable, httpapi.CodeUnavailable, "idempotency store unavailable").Wrap(err))
+			return
+		case rec != nil:
+			slog.InfoContext(r.Context(), "[idempotency] replay stored response", "status", rec.Status)
+			if rec.ContentType != "" {
+				w.Header().Set("Content-Type", rec.ContentType)
+			}
+			w.Header().Set(idempotency.ReplayedHeader, "true")
+			w.WriteHeader(rec.Status)
+			_, _ = w.Write(rec.Body)
+			return
+		}
+
+		// client 斷線也要記錄結果
+		ctx := context.WithoutCancel(r.Context())
+		r = r.WithContext(idempotency.WithSendTracker(r.Context()))
+		done := false
+		defer func() {
+			if done {
+				return
+			}
+			if idempotency.Sent(r.Context()) {
+				// 交易已送出但沒有回應（例如 panic），保留處理中的記錄直到過期，期間的重試回 409
+				slog.WarnContext(ctx, "[idempotency] request failed after sending a transaction, keep pending record")
+				return
+			}
+			if err := app.idempotency.Abort(ctx, storeKey, token, fingerprint); err != nil {
+				slog.ErrorContext(ctx, "[idempotency] drop pending record", "err", err)
+			}
+		}()
+
+		var buf bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&buf)
+		next.ServeHTTP(ww, r)
+		done = true
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusInternalServerError && !idempotency.Sent(r.Context()) {
+			if err := app.idempotency.Abort(ctx, storeKey, token, fingerprint); err != nil {
+				slog.ErrorContext(ctx, "[idempotency] drop failed response", "err", err)
+			}
+			return
+		}
+		err = app.idempotency.Complete(ctx, storeKey, token, idempotency.Record{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: ww.Header().Get("Content-Type"),
+			Body:        buf.Bytes(),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "[idempotency] store response", "err", err)
+		}
+	})
+}
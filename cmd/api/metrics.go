@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/wkchen007/nftweb-back/internal/metrics"
+)
+
+// balanceInterval 更新 signer 與合約餘額 gauge 的間隔
+const balanceInterval = time.Minute
+
+// updateBalances 定期更新 signer（各鏈）與各合集合約的餘額 gauge，直到 ctx 結束
+func (app *application) updateBalances(ctx context.Context) {
+	ticker := time.NewTicker(balanceInterval)
+	defer ticker.Stop()
+
+	for {
+		app.collectBalances(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (app *application) collectBalances(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, app.config.Timeouts.Call)
+	defer cancel()
+
+	// signer 可能被切換，先清掉舊地址的 series
+	metrics.SignerBalance.Reset()
+	for _, c := range app.chains.All() {
+		if !c.HasSigner() {
+			continue
+		}
+		wallet, err := c.GetBalance(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "[metrics] signer balance", "chain", c.Network(), "err", err)
+			continue
+		}
+		if eth, err := strconv.ParseFloat(wallet.BalanceEth, 64); err == nil {
+			metrics.SignerBalance.WithLabelValues(c.Network(), wallet.Address).Set(eth)
+		}
+	}
+
+	for _, h := range app.collections.All() {
+		svc := h.Service()
+		bal, err := svc.Balance(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "[metrics] contract balance", "collection", svc.Slug(), "err", err)
+			continue
+		}
+		if eth, err := strconv.ParseFloat(bal.BalanceETH, 64); err == nil {
+			metrics.ContractBalance.WithLabelValues(svc.Slug(), bal.Contract).Set(eth)
+		}
+	}
+}
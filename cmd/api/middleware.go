@@ -1,29 +1,220 @@
 package main
 
-import "net/http"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
+	"github.com/wkchen007/nftweb-back/internal/idempotency"
+	"github.com/wkchen007/nftweb-back/internal/logging"
+	"github.com/wkchen007/nftweb-back/internal/metrics"
+	"github.com/wkchen007/nftweb-back/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey string
+
+const claimsKey contextKey = "claims"
 
 func (app *application) enableCORS(h http.Handler) http.Handler {
+	cors := app.config.Policy.CORS
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
+		// 回應內容依 Origin 而不同，快取需分開存
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		allow := cors.AllowOrigin(r.Header.Get("Origin"))
+		if allow == "" {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
 
-		if r.Method == "OPTIONS" {
+		w.Header().Set("Access-Control-Allow-Origin", allow)
+		w.Header().Set("Access-Control-Expose-Headers", logging.RequestIDHeader+", "+idempotency.ReplayedHeader)
+		if cors.AllowCredentials {
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, X-CSRF-Token, Authorization")
+		}
+
+		if preflight {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(cors.AllowedMethods, ","))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(cors.AllowedHeaders, ", "))
+			if age := cors.MaxAgeHeader(); age != "" {
+				w.Header().Set("Access-Control-Max-Age", age)
+			}
+			w.WriteHeader(http.StatusNoContent)
 			return
-		} else {
-			h.ServeHTTP(w, r)
 		}
+		h.ServeHTTP(w, r)
 	})
 }
 
 func (app *application) authRequired(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, _, err := app.auth.GetTokenFromHeaderAndVerify(w, r)
+		_, claims, err := app.auth.GetTokenFromHeaderAndVerify(w, r)
 		if err != nil {
-			w.WriteHeader(http.StatusUnauthorized)
+			httpapi.WriteError(w, r, httpapi.Unauthorized().Wrap(err))
+			return
+		}
+		// 將 claims 放進 context，後續 handler 可取得登入的 user
+		ctx := context.WithValue(r.Context(), claimsKey, claims)
+		if info, ok := ctx.Value(accessInfoKey).(*accessInfo); ok {
+			info.userID = userIDFromContext(ctx)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// adminRequired 需放在 authRequired 之後；角色每次從 DB 讀取，降級或停用立即生效
+func (app *application) adminRequired(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := app.DB.GetUserByID(r.Context(), userIDFromContext(r.Context()))
+		if errors.Is(err, sql.ErrNoRows) {
+			httpapi.WriteError(w, r, httpapi.Unauthorized().Wrap(err))
+			return
+		}
+		if err != nil {
+			httpapi.WriteError(w, r, httpapi.Internal(err))
+			return
+		}
+		if !user.IsAdmin() {
+			httpapi.WriteError(w, r, httpapi.New(http.StatusForbidden, httpapi.CodeForbidden, "admin role required"))
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// userIDFromContext 取得 authRequired 驗證過的 user id（sub），未登入時回傳 0
+func userIDFromContext(ctx context.Context) int {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	if !ok || claims == nil {
+		return 0
+	}
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// metricsMiddleware 依 chi 路由樣板（例如 /collections/{slug}/mint）與狀態碼記錄延遲，避免 path 參數造成過多 label
+func (app *application) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route, strconv.Itoa(status)).Observe(metrics.Since(start))
+	})
+}
+
+// tracingMiddleware 接續上游的 traceparent 並為每個請求建立 server span；路由比對後以 chi 樣板命名
+func (app *application) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(attribute.String("http.route", rctx.RoutePattern()))
+		}
+		span.SetAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// requestID 沿用上游的 X-Request-ID（格式不合時重新產生），放進 ctx 與回應 header；
+// 錯誤回應與送到 RabbitMQ 的事件都會帶上
+func (app *application) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := logging.NewRequestID(r.Header.Get(logging.RequestIDHeader))
+		w.Header().Set(logging.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// accessInfo authRequired 驗證後填入 user id，讓外層的 access log 取得
+type accessInfo struct {
+	userID int
+}
+
+const accessInfoKey contextKey = "accessInfo"
+
+// accessLog 每個請求結束時記錄 method、route、status、耗時與 user id；探測用的路徑降為 debug
+func (app *application) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := &accessInfo{}
+		ctx := context.WithValue(r.Context(), accessInfoKey, info)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		route := ""
+		if rctx := chi.RouteContext(ctx); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case r.URL.Path == "/healthz" || r.URL.Path == "/readyz" || r.URL.Path == "/metrics":
+			level = slog.LevelDebug
+		}
+		slog.Log(ctx, level, "[http] access",
+			"method", r.Method,
+			"route", route,
+			"path", r.URL.Path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"bytes", ww.BytesWritten(),
+			"user_id", info.userID,
+			"remote", r.RemoteAddr,
+		)
+	})
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wkchen007/nftweb-back/internal/ethcli"
+)
+
+// readyTimeout 每個相依服務檢查的上限
+const readyTimeout = 2 * time.Second
+
+const (
+	readyOK       = "ok"
+	readyDegraded = "degraded" // 只有選用的相依服務失敗，仍可提供服務
+	readyFail     = "fail"
+)
+
+// ReadyCheck 單一相依服務的檢查結果
+type ReadyCheck struct {
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	Required  bool        `json:"required"`
+	LatencyMs int64       `json:"latencyMs"`
+	Error     string      `json:"error,omitempty"`
+	Detail    interface{} `json:"detail,omitempty"`
+}
+
+type ReadyResponse struct {
+	Status string         `json:"status"`
+	Checks []ReadyCheck   `json:"checks"`
+	Signer *ethcli.Wallet `json:"signer,omitempty"` // 僅供參考，不影響結果
+}
+
+// readyProbe 一個相依服務的檢查；required 失敗時整體為 fail，否則為 degraded
+type readyProbe struct {
+	name     string
+	required bool
+	check    func(ctx context.Context) (interface{}, error)
+}
+
+// readyzHandler 檢查 Postgres、Redis、RabbitMQ 與各鏈 RPC；必要的服務失敗回 503
+func (app *application) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	probes := []readyProbe{
+		{name: "postgres", required: true, check: func(ctx context.Context) (interface{}, error) {
+			return nil, app.DB.Connection().PingContext(ctx)
+		}},
+		{name: "redis", required: true, check: func(ctx context.Context) (interface{}, error) {
+			return nil, app.Redis.Ping(ctx).Err()
+		}},
+		// 只用於登入通知等 log / mail，斷線不影響主要功能
+		{name: "rabbitmq", check: func(ctx context.Context) (interface{}, error) {
+			if app.Amqp == nil || app.Amqp.IsClosed() {
+				return nil, fmt.Errorf("connection closed")
+			}
+			return nil, nil
+		}},
+	}
+	def := app.chains.Default()
+	for _, c := range app.chains.All() {
+		probes = append(probes, readyProbe{
+			name:     "rpc:" + c.Network(),
+			required: c == def,
+			check:    func(ctx context.Context) (interface{}, error) { return checkRPC(ctx, c) },
+		})
+	}
+
+	checks := make([]ReadyCheck, len(probes))
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checks[i] = runProbe(r.Context(), p)
+		}()
+	}
+
+	// signer 餘額只是資訊，失敗不影響結果
+	var signer *ethcli.Wallet
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+		if wallet, err := def.GetBalance(ctx); err == nil {
+			signer = &wallet
+		}
+	}()
+	wg.Wait()
+
+	resp := ReadyResponse{Status: readyOK, Checks: checks, Signer: signer}
+	for _, c := range checks {
+		switch {
+		case c.Status == readyOK:
+		case c.Required:
+			resp.Status = readyFail
+		case resp.Status == readyOK:
+			resp.Status = readyDegraded
+		}
+	}
+
+	status := http.StatusOK
+	if resp.Status == readyFail {
+		status = http.StatusServiceUnavailable
+	}
+	_ = app.writeJSON(w, status, resp)
+}
+
+func runProbe(ctx context.Context, p readyProbe) ReadyCheck {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	start := time.Now()
+	detail, err := p.check(ctx)
+	c := ReadyCheck{
+		Name:      p.name,
+		Status:    readyOK,
+		Required:  p.required,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Detail:    detail,
+	}
+	if err != nil {
+		c.Status = readyFail
+		if !p.required {
+			c.Status = readyDegraded
+		}
+		c.Error = err.Error()
+	}
+	return c
+}
+
+// checkRPC 取得最新區塊並確認節點回報的 chain id 與設定相同
+func checkRPC(ctx context.Context, c *ethcli.Client) (interface{}, error) {
+	block, err := c.Backend().BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("block number: %w", err)
+	}
+	id, err := c.Backend().ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("chain id: %w", err)
+	}
+	if id.Uint64() != c.Chain().ID {
+		return nil, fmt.Errorf("chain id %d, want %d", id.Uint64(), c.Chain().ID)
+	}
+
+	return struct {
+		ChainID     uint64 `json:"chainId"`
+		BlockNumber uint64 `json:"blockNumber"`
+	}{id.Uint64(), block}, nil
+}
@@ -2,13 +2,13 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
 	"github.com/redis/go-redis/v9"
 )
 
 func (app *application) connectToRedis() (*redis.Client, error) {
-	opt, err := redis.ParseURL(app.redisURL)
+	opt, err := redis.ParseURL(app.config.RedisURL.Value())
 	if err != nil {
 		return nil, err
 	}
@@ -17,6 +17,6 @@ func (app *application) connectToRedis() (*redis.Client, error) {
 		return nil, err
 	}
 
-	log.Println("Connected to Redis!")
+	slog.Info("[redis] connected to Redis")
 	return rdb, nil
 }
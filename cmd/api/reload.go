@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
+	"github.com/wkchen007/nftweb-back/internal/nft"
+)
+
+// poller 一個合集的事件輪詢
+type poller struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startPolling 以 svc 開始輪詢合集的合約事件（呼叫端需持有 reloadMu）。
+// reload 時先停掉同合集舊的並等它結束，新的再從 DB 存下的進度繼續，不會從部署區塊重播
+func (app *application) startPolling(svc *nft.Service) {
+	if app.polls == nil {
+		app.polls = map[string]*poller{}
+	}
+	if old, ok := app.polls[svc.Slug()]; ok {
+		old.cancel()
+		select {
+		case <-old.done:
+		case <-app.workers.Context().Done():
+		}
+	}
+	pollCtx, cancel := context.WithCancel(app.workers.Context())
+	p := &poller{cancel: cancel, done: make(chan struct{})}
+	app.polls[svc.Slug()] = p
+	app.workers.Go(func(context.Context) {
+		defer close(p.done)
+		svc.PollEvents(pollCtx, 15*time.Second, app.webhooks.Publish)
+	})
+}
+
+// reloadNFT 重新讀取設定檔的合集設定與 ABI，替換各合集 Handlers 使用的 Service
+func (app *application) reloadNFT() ([]string, error) {
+	app.reloadMu.Lock()
+	defer app.reloadMu.Unlock()
+
+	cfg, err := nft.LoadConfig(app.config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, changed, err := app.collections.Reload(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range changed {
+		app.startPolling(svc)
+	}
+
+	if len(diff) == 0 {
+		slog.Info("[nft] config reloaded: no changes (abi reloaded)")
+	}
+	for _, d := range diff {
+		slog.Info("[nft] config reloaded", "change", d)
+	}
+	return diff, nil
+}
+
+// watchSIGHUP 收到 SIGHUP 時 reload，直到 ctx 結束
+func (app *application) watchSIGHUP(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			if _, err := app.reloadNFT(); err != nil {
+				slog.ErrorContext(ctx, "[nft] reload failed, keep current config", "err", err)
+			}
+		}
+	}
+}
+
+// ReloadNFT 管理用 endpoint：與 SIGHUP 相同的 reload
+func (app *application) ReloadNFT(w http.ResponseWriter, r *http.Request) {
+	diff, err := app.reloadNFT()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "[nft] reload failed, keep current config", "err", err)
+		httpapi.WriteError(w, r, httpapi.New(http.StatusBadRequest, httpapi.CodeBadRequest, "nft config reload failed, keep current config").Wrap(err))
+		return
+	}
+
+	_ = app.writeJSON(w, http.StatusOK, JSONResponse{Message: "nft config reloaded", Data: diff})
+}
@@ -5,40 +5,116 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/wkchen007/nftweb-back/internal/metrics"
+	"github.com/wkchen007/nftweb-back/internal/nft"
+	"github.com/wkchen007/nftweb-back/internal/ratelimit"
 )
 
 func (app *application) routes() http.Handler {
 	// create a router mux
 	mux := chi.NewRouter()
 
+	mux.Use(app.requestID)
+	mux.Use(app.tracingMiddleware)
+	mux.Use(app.accessLog)
+	mux.Use(app.metricsMiddleware)
 	mux.Use(middleware.Recoverer)
 	mux.Use(app.enableCORS)
+	mux.Use(app.rateLimit(ratelimit.GroupDefault))
+
+	auth := app.rateLimit(ratelimit.GroupAuth)
+	wallet := app.rateLimit(ratelimit.GroupWallet)
 
 	mux.Get("/", app.Home)
 	mux.Get("/healthz", app.healthzHandler)
-	mux.Post("/authenticate", app.authenticate)
-	mux.Post("/logout", app.logout)
+	mux.Get("/readyz", app.readyzHandler)
+	mux.Handle("/metrics", metrics.Handler())
+	mux.With(auth).Post("/authenticate", app.authenticate)
+	mux.With(auth).Post("/logout", app.logout)
 	mux.Get("/demo", app.AllNFTs)
+	mux.Get("/chains", app.Chains)
 
 	mux.Route("/wallet", func(mux chi.Router) {
 		mux.Use(app.authRequired)
-		mux.Post("/useSigner", app.PostWalletUseSigner)
+		mux.Get("/signers", app.GetWalletSigners)
+		mux.With(app.adminRequired).Post("/useSigner", app.PostWalletUseSigner)
 		mux.Post("/address", app.GetWalletAddress)
 		mux.Post("/balance", app.GetWalletBalance)
-		mux.Post("/transfer", app.PostWalletTransfer)
+		mux.With(wallet, app.idempotent).Post("/transfer", app.PostWalletTransfer)
 	})
 
 	mux.Route("/nft", func(mux chi.Router) {
-		mux.Get("/owner", app.nft.Owner)
-		mux.Post("/ownerOf", app.nft.OwnerOf)
-		mux.Post("/mint", app.nft.Mint)
-		mux.Post("/tokensOfOwner", app.nft.TokensOfOwner)
-		mux.Get("/openBlindBox", app.nft.OpenBlindBox)
-		mux.Get("/tokenURI/{id}", app.nft.TokenURI)
-		mux.Get("/withdraw", app.nft.Withdraw)
-		mux.Get("/balance", app.nft.Balance)
-		mux.Get("/count", app.nft.Count)
+		app.nftRoutes(mux, app.defaultCollection)
+		// ownerOf 可帶 contract 欄位查詢任一合集
+		mux.Post("/ownerOf", app.collections.OwnerOf)
+	})
+
+	mux.Get("/collections", app.Collections)
+	mux.Route("/collections/{slug}", func(mux chi.Router) {
+		app.nftRoutes(mux, app.slugCollection)
+		mux.Post("/ownerOf", app.collection(app.slugCollection, (*nft.Handlers).OwnerOf))
+	})
+
+	mux.Route("/admin", func(mux chi.Router) {
+		mux.Use(app.authRequired, app.adminRequired)
+		mux.Post("/reload", app.ReloadNFT)
+	})
+
+	mux.Route("/catalog", func(mux chi.Router) {
+		mux.Use(app.authRequired)
+		mux.Get("/", app.CatalogItems)
+		mux.With(app.adminRequired).Post("/", app.InsertCatalogItem)
+		mux.Get("/audit", app.CatalogAudit)
+		mux.Get("/{id}", app.GetCatalogItem)
+		mux.With(app.adminRequired).Put("/{id}", app.UpdateCatalogItem)
+		mux.With(app.adminRequired).Delete("/{id}", app.DeleteCatalogItem)
+	})
+
+	mux.Route("/webhooks", func(mux chi.Router) {
+		mux.Use(app.authRequired)
+		mux.Get("/", app.webhook.List)
+		mux.Post("/", app.webhook.Subscribe)
+		mux.Delete("/{id}", app.webhook.Unsubscribe)
+		mux.Get("/{id}/deliveries", app.webhook.Deliveries)
+		mux.Get("/deliveries/{id}/attempts", app.webhook.Attempts)
+		mux.Post("/deliveries/{id}/replay", app.webhook.Replay)
 	})
 
 	return mux
 }
+
+// nftRoutes 掛載單一合集的 nft 路由，pick 決定使用哪個合集
+func (app *application) nftRoutes(mux chi.Router, pick func(*http.Request) (*nft.Handlers, bool)) {
+	h := func(fn nftHandler) http.HandlerFunc {
+		return app.collection(pick, fn)
+	}
+	scan := app.rateLimit(ratelimit.GroupScan)
+	wallet := app.rateLimit(ratelimit.GroupWallet)
+
+	mux.Get("/owner", h((*nft.Handlers).Owner))
+	mux.With(wallet, app.idempotent).Post("/mint", h((*nft.Handlers).Mint))
+	mux.With(scan).Post("/tokensOfOwner", h((*nft.Handlers).TokensOfOwner))
+	mux.With(wallet).Get("/openBlindBox", h((*nft.Handlers).OpenBlindBox))
+	mux.Get("/tokenURI/{id}", h((*nft.Handlers).TokenURI))
+	mux.With(wallet).Get("/withdraw", h((*nft.Handlers).Withdraw))
+	mux.Get("/balance", h((*nft.Handlers).Balance))
+	mux.Get("/count", h((*nft.Handlers).Count))
+	mux.With(scan).Get("/stats", h((*nft.Handlers).Stats))
+	mux.Get("/metadata/{id}", h((*nft.Handlers).TokenMetadata))
+	mux.Get("/assignment", h((*nft.Handlers).Assignment))
+	mux.Get("/odds", h((*nft.Handlers).Odds))
+
+	mux.Group(func(mux chi.Router) {
+		mux.Use(app.authRequired)
+		mux.Get("/snapshots", h((*nft.Handlers).Snapshots))
+		mux.With(scan, app.idempotent).Post("/snapshots", h((*nft.Handlers).CreateSnapshot))
+		mux.Get("/snapshots/{id}", h((*nft.Handlers).GetSnapshot))
+		mux.Get("/snapshots/{id}/diff/{other}", h((*nft.Handlers).DiffSnapshot))
+		mux.Get("/airdrops", h((*nft.Handlers).Airdrops))
+		mux.With(app.adminRequired, wallet, app.idempotent).Post("/airdrops", h((*nft.Handlers).CreateAirdrop))
+		mux.Get("/airdrops/{id}", h((*nft.Handlers).GetAirdrop))
+		mux.With(app.adminRequired, wallet, app.idempotent).Post("/airdrops/{id}/resume", h((*nft.Handlers).ResumeAirdrop))
+		mux.With(app.adminRequired).Post("/assignment", h((*nft.Handlers).CommitAssignment))
+		mux.With(app.adminRequired).Post("/assignment/reveal", h((*nft.Handlers).RevealAssignment))
+	})
+}
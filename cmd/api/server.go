@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+)
+
+// serve 啟動 HTTP(S) server，直到收到 SIGINT / SIGTERM 後優雅關閉：
+// 停止接受新連線並等待進行中的請求，再停止背景工作；
+// 回傳後由 main 的 defer 依序關閉 signer、以太連線、Redis、RabbitMQ 與 Postgres
+func (app *application) serve() error {
+	s := app.config.Server
+	srv := &http.Server{
+		Addr:              app.config.HTTPAddr,
+		Handler:           app.routes(),
+		ReadHeaderTimeout: s.ReadHeaderTimeout,
+		ReadTimeout:       s.ReadTimeout,
+		WriteTimeout:      s.WriteTimeout,
+		IdleTimeout:       s.IdleTimeout,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if s.TLS() {
+			slog.Info("[http] listening", "addr", srv.Addr, "tls", true)
+			err = srv.ListenAndServeTLS(s.TLSCertFile, s.TLSKeyFile)
+		} else {
+			slog.Info("[http] listening", "addr", srv.Addr, "tls", false)
+			err = srv.ListenAndServe()
+		}
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		// 啟動失敗（例如 port 被占用）也要停掉背景工作
+		app.stopWorkers()
+		return err
+	case <-ctx.Done():
+	}
+	// 再收到一次訊號就直接結束
+	stop()
+	slog.Info("[http] shutting down, waiting for in-flight requests", "timeout", s.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Error("[http] shutdown", "err", err)
+		_ = srv.Close()
+	}
+	if serveErr := <-errCh; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+
+	if werr := app.workers.Stop(shutdownCtx); werr != nil {
+		slog.Error("[http] background workers did not stop in time", "err", werr)
+		err = errors.Join(err, werr)
+	}
+	slog.Info("[http] server stopped")
+	return err
+}
+
+// stopWorkers 停止背景工作，最多等待 shutdownTimeout
+func (app *application) stopWorkers() {
+	ctx, cancel := context.WithTimeout(context.Background(), app.config.Server.ShutdownTimeout)
+	defer cancel()
+	if err := app.workers.Stop(ctx); err != nil {
+		slog.Error("[http] background workers did not stop in time", "err", err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wkchen007/nftweb-back/internal/config"
+	"github.com/wkchen007/nftweb-back/internal/ethcli"
+)
+
+// newKeyring 依 signer / signers 設定建立所有簽章來源；啟動時建立一次，API 只能以 id 切換
+func newKeyring(ctx context.Context, cfg *config.Config) (*ethcli.Keyring, error) {
+	keys := ethcli.NewKeyring()
+	for _, sc := range cfg.SignerList() {
+		s, err := newSigner(ctx, sc)
+		if err != nil {
+			keys.Close()
+			return nil, fmt.Errorf("signer %s: %w", sc.ID, err)
+		}
+		if err := keys.Add(sc.ID, s); err != nil {
+			keys.Close()
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// newSigner 依單一 signer 設定建立簽章來源
+func newSigner(ctx context.Context, sc config.SignerConfig) (ethcli.Signer, error) {
+	switch sc.Type {
+	case config.SignerLocal:
+		return ethcli.NewKeySigner(sc.PrivateKey.Value())
+	case config.SignerKeystore:
+		return ethcli.NewKeystoreSigner(sc.KeystorePath, sc.KeystorePassword.Value())
+	case config.SignerClef:
+		return ethcli.NewClefSigner(ctx, sc.URL, sc.Address)
+	default:
+		return nil, fmt.Errorf("unknown signer type %q", sc.Type)
+	}
+}
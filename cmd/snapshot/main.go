@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/joho/godotenv"
+	"github.com/wkchen007/nftweb-back/internal/config"
+	"github.com/wkchen007/nftweb-back/internal/ethcli"
+	"github.com/wkchen007/nftweb-back/internal/nft"
+	"github.com/wkchen007/nftweb-back/internal/repository/dbrepo"
+)
+
+// snapshot 指令：輸出指定區塊高度時每個地址持有的 token，可選擇存入 Postgres 以便日後比對
+//
+//	go run ./cmd/snapshot -block 9000000 -format csv -out holders.csv -store -collection default
+func main() {
+	if err := run(); err != nil {
+		slog.Error("[snapshot] failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+// run 執行快照；以回傳錯誤結束而不是 log.Fatal，defer 的連線與檔案才會關閉
+func run() error {
+	var (
+		block  uint64
+		format string
+		out    string
+		store  bool
+		slug   string
+	)
+
+	// 載入 .env 檔案
+	if err := godotenv.Load(); err != nil {
+		slog.Info("[snapshot] no .env file found, skip loading")
+	}
+
+	// 連線設定（-config / -dsn / -rpcURL 等）與 API 服務共用
+	loader := config.RegisterFlags(flag.CommandLine)
+	flag.Uint64Var(&block, "block", 0, "block number (0 = latest)")
+	flag.StringVar(&format, "format", "csv", "output format: csv or json")
+	flag.StringVar(&out, "out", "", "output file (default stdout)")
+	flag.BoolVar(&store, "store", false, "store the snapshot in Postgres")
+	flag.StringVar(&slug, "collection", nft.DefaultSlug, "collection slug")
+	flag.Parse()
+
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if err := cfg.ValidateNFT(); err != nil {
+		return fmt.Errorf("invalid config (%s):\n%w", cfg.Path, err)
+	}
+	if store && cfg.DSN == "" {
+		return errors.New("-store requires dsn (DSN env or -dsn)")
+	}
+
+	chains, err := ethcli.DialChains(context.Background(), cfg.ChainList(), cfg.DefaultChain)
+	if err != nil {
+		return fmt.Errorf("create eth client: %w", err)
+	}
+	defer chains.Close()
+
+	var col *nft.CollectionConfig
+	for _, c := range cfg.All() {
+		if c.Slug == slug {
+			col = &c
+			break
+		}
+	}
+	if col == nil {
+		return fmt.Errorf("collection %s not found in %s", slug, cfg.Path)
+	}
+
+	ethc, err := chains.Get(col.Chain)
+	if err != nil {
+		return err
+	}
+	svc, err := nft.NewCollectionService(ethc, *col)
+	if err != nil {
+		return fmt.Errorf("create nft service: %w", err)
+	}
+	svc.SetTimeouts(cfg.Timeouts.Timeouts)
+
+	snap, err := svc.Snapshot(context.Background(), block)
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	slog.Info("[snapshot] done", "block", snap.BlockNumber, "holders", len(snap.Holders), "source", snap.Source)
+
+	if store {
+		db, err := sql.Open("pgx", cfg.DSN.Value())
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		repo := &dbrepo.PostgresDBRepo{DB: db, Timeout: cfg.Timeouts.DB}
+		snap.ID, err = repo.InsertHolderSnapshot(context.Background(), snap)
+		if err != nil {
+			return fmt.Errorf("store snapshot: %w", err)
+		}
+		slog.Info("[snapshot] stored", "id", snap.ID)
+	}
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(snap)
+	} else {
+		err = nft.WriteSnapshotCSV(w, snap)
+	}
+	if err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,512 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/wkchen007/nftweb-back/internal/ethcli"
+	"github.com/wkchen007/nftweb-back/internal/idempotency"
+	"github.com/wkchen007/nftweb-back/internal/lockout"
+	"github.com/wkchen007/nftweb-back/internal/nft"
+	"github.com/wkchen007/nftweb-back/internal/ratelimit"
+	"github.com/wkchen007/nftweb-back/internal/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+const DefaultPath = "configs/config.yaml"
+
+// Config 所有服務設定；優先順序：預設值 < YAML < 環境變數 < 命令列參數
+type Config struct {
+	Env         string         `yaml:"env"`
+	HTTPAddr    string         `yaml:"httpAddr"`
+	Server      ServerConfig   `yaml:"server"`
+	DSN         Secret         `yaml:"dsn"`
+	AmqpURL     Secret         `yaml:"amqpURL"`
+	RedisURL    Secret         `yaml:"redisURL"`
+	RPCURL      Secret         `yaml:"rpcURL"`     // 預設鏈的 RPC URL，會排在 chains 的 rpcURLs 之前
+	PrivateKey  Secret         `yaml:"privateKey"` // signer.type 為 local 時使用
+	Signer      SignerConfig   `yaml:"signer"`     // 預設 signer（id 預設為 default）
+	Signers     []SignerConfig `yaml:"signers"`    // 其他預先註冊的 signer，以 id 切換
+	JWT         JWTConfig      `yaml:"jwt"`
+	nft.Config  `yaml:",inline"`
+	Policy      Policy             `yaml:"policy"`
+	Timeouts    TimeoutConfig      `yaml:"timeouts"`
+	Tracing     tracing.Config     `yaml:"tracing"`
+	RateLimit   ratelimit.Config   `yaml:"rateLimit"`
+	Lockout     lockout.Config     `yaml:"lockout"`
+	Idempotency idempotency.Config `yaml:"idempotency"`
+
+	DefaultChain uint64         `yaml:"defaultChain"` // 未指定 chain 的合集與錢包請求使用的鏈
+	Chains       []ethcli.Chain `yaml:"chains"`       // 與內建鏈資料合併（相同 id 覆寫）
+
+	Path string `yaml:"-"` // 實際讀取的 YAML 路徑
+}
+
+// SignerConfig 交易簽章來源：local（privateKey）、keystore（加密 JSON 檔）或 clef（遠端 signer）
+type SignerConfig struct {
+	ID               string `yaml:"id"`
+	Type             string `yaml:"type"`
+	PrivateKey       Secret `yaml:"privateKey"` // local 使用；預設 signer 未設定時沿用頂層 privateKey
+	KeystorePath     string `yaml:"keystorePath"`
+	KeystorePassword Secret `yaml:"keystorePassword"`
+	URL              string `yaml:"url"`     // clef 的 HTTP / WebSocket URL 或 IPC 路徑
+	Address          string `yaml:"address"` // clef 使用的帳號，空白時取第一個
+}
+
+const (
+	DefaultSignerID = "default"
+
+	SignerLocal    = "local"
+	SignerKeystore = "keystore"
+	SignerClef     = "clef"
+)
+
+// ServerConfig HTTP server 的逾時、優雅關閉與 TLS；cert 與 key 都設定時以 HTTPS 提供服務
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	ReadTimeout       time.Duration `yaml:"readTimeout"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout"` // 需大於 timeouts.tx，否則送交易的回應會被截斷
+	IdleTimeout       time.Duration `yaml:"idleTimeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdownTimeout"` // 收到 SIGTERM 後等待進行中請求與背景工作的上限
+	TLSCertFile       string        `yaml:"tlsCertFile"`
+	TLSKeyFile        string        `yaml:"tlsKeyFile"`
+}
+
+// TLS 是否啟用 HTTPS
+func (s ServerConfig) TLS() bool {
+	return s.TLSCertFile != "" && s.TLSKeyFile != ""
+}
+
+// TimeoutConfig 各類操作的上限；都建立在 request context 之上，client 斷線或關閉服務時會提早取消
+type TimeoutConfig struct {
+	DB           time.Duration `yaml:"db"` // 單次 SQL
+	nft.Timeouts `yaml:",inline"`
+}
+
+type JWTConfig struct {
+	Issuer        string        `yaml:"issuer"`
+	Audience      string        `yaml:"audience"`
+	Secret        Secret        `yaml:"secret"`
+	TokenExpiry   time.Duration `yaml:"tokenExpiry"`
+	RefreshExpiry time.Duration `yaml:"refreshExpiry"`
+	CookieDomain  string        `yaml:"cookieDomain"`
+}
+
+// Default 未設定任何來源時的值；連線字串與密鑰沒有預設，必須明確設定
+func Default() Config {
+	return Config{
+		Env:      "development",
+		HTTPAddr: ":8080",
+		Server: ServerConfig{
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		// 目前合約部署在 Sepolia
+		DefaultChain: 11155111,
+		JWT: JWTConfig{
+			TokenExpiry:   5 * time.Minute,
+			RefreshExpiry: 24 * time.Hour,
+		},
+		Signer:      SignerConfig{Type: SignerLocal},
+		Policy:      DefaultPolicy(),
+		RateLimit:   ratelimit.DefaultConfig(),
+		Lockout:     lockout.DefaultConfig(),
+		Idempotency: idempotency.DefaultConfig(),
+		Tracing: tracing.Config{
+			ServiceName: "nftweb-back",
+			SampleRatio: 1,
+		},
+		Timeouts: TimeoutConfig{
+			DB:       3 * time.Second,
+			Timeouts: nft.DefaultTimeouts(),
+		},
+	}
+}
+
+// envVars 環境變數對應；每個變數都支援 <NAME>_FILE 從檔案讀取（適用 Docker / k8s secrets）
+var envVars = []struct {
+	name string
+	set  func(c *Config, v string)
+}{
+	{"APP_ENV", func(c *Config, v string) { c.Env = v }},
+	{"HTTP_ADDR", func(c *Config, v string) { c.HTTPAddr = v }},
+	{"TLS_CERT_FILE", func(c *Config, v string) { c.Server.TLSCertFile = v }},
+	{"TLS_KEY_FILE", func(c *Config, v string) { c.Server.TLSKeyFile = v }},
+	{"DSN", func(c *Config, v string) { c.DSN = Secret(v) }},
+	{"AMQP_URL", func(c *Config, v string) { c.AmqpURL = Secret(v) }},
+	{"REDIS_URL", func(c *Config, v string) { c.RedisURL = Secret(v) }},
+	{"RPC_URL", func(c *Config, v string) { c.RPCURL = Secret(v) }},
+	{"PRIVATE_KEY", func(c *Config, v string) { c.PrivateKey = Secret(v) }},
+	{"SIGNER_TYPE", func(c *Config, v string) { c.Signer.Type = v }},
+	{"SIGNER_KEYSTORE", func(c *Config, v string) { c.Signer.KeystorePath = v }},
+	{"SIGNER_KEYSTORE_PASSWORD", func(c *Config, v string) { c.Signer.KeystorePassword = Secret(v) }},
+	{"SIGNER_URL", func(c *Config, v string) { c.Signer.URL = v }},
+	{"SIGNER_ADDRESS", func(c *Config, v string) { c.Signer.Address = v }},
+	{"JWT_SECRET", func(c *Config, v string) { c.JWT.Secret = Secret(v) }},
+	{"JWT_ISSUER", func(c *Config, v string) { c.JWT.Issuer = v }},
+	{"JWT_AUDIENCE", func(c *Config, v string) { c.JWT.Audience = v }},
+	{"COOKIE_DOMAIN", func(c *Config, v string) { c.JWT.CookieDomain = v }},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", func(c *Config, v string) { c.Tracing.Endpoint = v }},
+	{"OTEL_SERVICE_NAME", func(c *Config, v string) { c.Tracing.ServiceName = v }},
+}
+
+// Loader 註冊共用的命令列參數，Parse 之後再呼叫 Load
+type Loader struct {
+	fs    *flag.FlagSet
+	path  string
+	print bool
+	flags map[string]*string
+}
+
+// RegisterFlags 在 fs 上註冊 -config、-printConfig 與可覆寫設定的參數
+func RegisterFlags(fs *flag.FlagSet) *Loader {
+	l := &Loader{fs: fs, flags: map[string]*string{}}
+	fs.StringVar(&l.path, "config", "", "config file path (default $CONFIG_PATH or "+DefaultPath+")")
+	fs.BoolVar(&l.print, "printConfig", false, "print the effective config with secrets redacted and exit")
+	for _, name := range []string{"httpAddr", "dsn", "amqpURL", "redisURL", "rpcURL"} {
+		l.flags[name] = fs.String(name, "", name+" (overrides config file and environment)")
+	}
+	return l
+}
+
+// PrintConfig 是否帶了 -printConfig
+func (l *Loader) PrintConfig() bool {
+	return l.print
+}
+
+// Load 依序套用預設值、YAML、環境變數與有明確指定的命令列參數（不做驗證）
+func (l *Loader) Load() (*Config, error) {
+	cfg := Default()
+
+	cfg.Path = l.path
+	if cfg.Path == "" {
+		cfg.Path = os.Getenv("CONFIG_PATH")
+	}
+	if cfg.Path == "" {
+		cfg.Path = DefaultPath
+	}
+	data, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", cfg.Path, err)
+	}
+
+	for _, e := range envVars {
+		v, ok, err := lookupEnv(e.name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			e.set(&cfg, v)
+		}
+	}
+
+	cfg.Chains = ethcli.MergeChains(ethcli.DefaultChains(), cfg.Chains)
+
+	l.fs.Visit(func(f *flag.Flag) {
+		v := f.Value.String()
+		switch f.Name {
+		case "httpAddr":
+			cfg.HTTPAddr = v
+		case "dsn":
+			cfg.DSN = Secret(v)
+		case "amqpURL":
+			cfg.AmqpURL = Secret(v)
+		case "redisURL":
+			cfg.RedisURL = Secret(v)
+		case "rpcURL":
+			cfg.RPCURL = Secret(v)
+		}
+	})
+
+	return &cfg, nil
+}
+
+// lookupEnv 讀取 NAME 或 NAME_FILE（兩者同時設定視為錯誤）
+func lookupEnv(name string) (string, bool, error) {
+	v, ok := os.LookupEnv(name)
+	file, fileOK := os.LookupEnv(name + "_FILE")
+	if !fileOK {
+		return v, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("both %s and %s_FILE are set", name, name)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", name, err)
+	}
+	return strings.TrimSpace(string(b)), true, nil
+}
+
+var privateKeyHex = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{64}$`)
+
+// Validate 檢查 API 服務需要的所有欄位，一次回傳所有錯誤
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Env == "" {
+		add("env is required")
+	}
+	if _, _, err := net.SplitHostPort(c.HTTPAddr); err != nil {
+		add("httpAddr: %v", err)
+	}
+	if err := c.Server.Validate(c.Timeouts.Tx); err != nil {
+		add("server: %v", err)
+	}
+	if c.DSN == "" {
+		add("dsn is required")
+	}
+	if err := checkURL(c.AmqpURL, "amqp", "amqps"); err != nil {
+		add("amqpURL: %v", err)
+	}
+	if err := checkURL(c.RedisURL, "redis", "rediss"); err != nil {
+		add("redisURL: %v", err)
+	}
+	ids := map[string]bool{}
+	for i, sc := range c.SignerList() {
+		field := "signer"
+		if i > 0 {
+			field = fmt.Sprintf("signers[%d]", i-1)
+		}
+		if sc.ID == "" {
+			add("%s.id is required", field)
+		} else if ids[sc.ID] {
+			add("%s: duplicate id %q", field, sc.ID)
+		}
+		ids[sc.ID] = true
+		if err := sc.Validate(); err != nil {
+			add("%s: %v", field, err)
+		}
+	}
+
+	if c.JWT.Issuer == "" {
+		add("jwt.issuer is required")
+	}
+	if c.JWT.Audience == "" {
+		add("jwt.audience is required")
+	}
+	// HS256 的金鑰至少要 256 bits，空字串會讓任何人都能簽出合法 token
+	if len(c.JWT.Secret) < 32 {
+		add("jwt.secret must be at least 32 bytes")
+	}
+	if c.JWT.TokenExpiry <= 0 || c.JWT.RefreshExpiry <= 0 {
+		add("jwt.tokenExpiry and jwt.refreshExpiry must be positive")
+	}
+
+	if err := c.ValidateNFT(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Policy.Validate(); err != nil {
+		add("policy: %v", err)
+	}
+	if err := c.RateLimit.Validate(); err != nil {
+		add("rateLimit: %v", err)
+	}
+	if err := c.Lockout.Validate(); err != nil {
+		add("lockout: %v", err)
+	}
+	if err := c.Idempotency.Validate(); err != nil {
+		add("idempotency: %v", err)
+	} else if c.Idempotency.PendingTTL <= c.Timeouts.Tx {
+		// 處理中的記錄比送交易先過期時，重試會被當成新請求而重複送出
+		add("idempotency.pendingTTL (%s) must be longer than timeouts.tx (%s)", c.Idempotency.PendingTTL, c.Timeouts.Tx)
+	}
+	if c.Tracing.Endpoint != "" {
+		if err := checkURL(Secret(c.Tracing.Endpoint), "http", "https"); err != nil {
+			add("tracing.endpoint: %v", err)
+		}
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sampleRatio must be between 0 and 1")
+	}
+	t := c.Timeouts
+	for name, d := range map[string]time.Duration{"db": t.DB, "call": t.Call, "tx": t.Tx, "scan": t.Scan, "snapshot": t.Snapshot, "poll": t.Poll} {
+		if d <= 0 {
+			add("timeouts.%s must be positive", name)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate 檢查逾時與 TLS 檔案；tx 為送交易的上限，寫出回應的時間必須比它長
+func (s ServerConfig) Validate(tx time.Duration) error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	for name, d := range map[string]time.Duration{
+		"readHeaderTimeout": s.ReadHeaderTimeout,
+		"readTimeout":       s.ReadTimeout,
+		"writeTimeout":      s.WriteTimeout,
+		"idleTimeout":       s.IdleTimeout,
+		"shutdownTimeout":   s.ShutdownTimeout,
+	} {
+		if d <= 0 {
+			add("%s must be positive", name)
+		}
+	}
+	if s.WriteTimeout > 0 && s.WriteTimeout <= tx {
+		add("writeTimeout (%s) must be longer than timeouts.tx (%s)", s.WriteTimeout, tx)
+	}
+
+	if (s.TLSCertFile == "") != (s.TLSKeyFile == "") {
+		add("tlsCertFile and tlsKeyFile must be set together")
+	}
+	for _, f := range []string{s.TLSCertFile, s.TLSKeyFile} {
+		if f == "" {
+			continue
+		}
+		if _, err := os.Stat(f); err != nil {
+			add("%v", err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SignerList 回傳所有 signer 設定（預設 signer 在第一個）
+func (c *Config) SignerList() []SignerConfig {
+	def := c.Signer
+	if def.ID == "" {
+		def.ID = DefaultSignerID
+	}
+	if def.PrivateKey == "" {
+		def.PrivateKey = c.PrivateKey
+	}
+	return append([]SignerConfig{def}, c.Signers...)
+}
+
+// Validate 檢查單一 signer 設定
+func (s SignerConfig) Validate() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch s.Type {
+	case SignerLocal:
+		if !privateKeyHex.MatchString(s.PrivateKey.Value()) {
+			add("privateKey must be 32 bytes hex")
+		}
+	case SignerKeystore:
+		if s.KeystorePath == "" {
+			add("keystorePath is required")
+		} else if _, err := os.Stat(s.KeystorePath); err != nil {
+			add("keystorePath: %v", err)
+		}
+	case SignerClef:
+		if s.URL == "" {
+			add("url is required")
+		}
+		if s.Address != "" && !ethcli.IsHexAddress(s.Address) {
+			add("address is not a valid address")
+		}
+	default:
+		add("type must be one of %s, %s, %s", SignerLocal, SignerKeystore, SignerClef)
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateNFT 只檢查連鏈與合約相關設定（給不需要 DB / JWT 的 CLI 使用）
+func (c *Config) ValidateNFT() error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.RPCURL != "" {
+		if err := checkURL(c.RPCURL, rpcSchemes...); err != nil {
+			add("rpcURL: %v", err)
+		}
+	}
+	connected := map[uint64]bool{}
+	for _, chain := range c.ChainList() {
+		if err := chain.Validate(); err != nil {
+			add("chains: %v", err)
+		}
+		for i, u := range chain.RPCURLs {
+			if err := checkURL(Secret(u), rpcSchemes...); err != nil {
+				add("chains %d rpcURLs[%d]: %v", chain.ID, i, err)
+			}
+		}
+		connected[chain.ID] = len(chain.RPCURLs) > 0
+	}
+	if !connected[c.DefaultChain] {
+		add("defaultChain %d needs rpcURL or chains[].rpcURLs", c.DefaultChain)
+	}
+	for _, col := range c.All() {
+		if col.Chain != 0 && !connected[col.Chain] {
+			add("collection %s: chain %d has no rpcURLs", col.Slug, col.Chain)
+		}
+	}
+	if err := c.Config.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+var rpcSchemes = []string{"http", "https", "ws", "wss"}
+
+// ChainList 回傳鏈設定，rpcURL 會加在預設鏈 rpcURLs 的最前面
+func (c *Config) ChainList() []ethcli.Chain {
+	chains := make([]ethcli.Chain, 0, len(c.Chains))
+	for _, chain := range c.Chains {
+		if chain.ID == c.DefaultChain && c.RPCURL != "" {
+			chain.RPCURLs = append([]string{c.RPCURL.Value()}, chain.RPCURLs...)
+		}
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+func checkURL(s Secret, schemes ...string) error {
+	if s == "" {
+		return fmt.Errorf("is required")
+	}
+	u, err := url.Parse(s.Value())
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid url")
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("scheme must be one of %s", strings.Join(schemes, ", "))
+}
+
+// Redacted 以 YAML 輸出實際生效的設定，密鑰已遮蔽
+func (c *Config) Redacted() ([]byte, error) {
+	out := *c
+	// RPC URL 常帶 API key
+	out.Chains = make([]ethcli.Chain, len(c.Chains))
+	for i, chain := range c.Chains {
+		urls := make([]string, len(chain.RPCURLs))
+		for j := range urls {
+			urls[j] = Secret(chain.RPCURLs[j]).String()
+		}
+		chain.RPCURLs = urls
+		out.Chains[i] = chain
+	}
+	return yaml.Marshal(&out)
+}
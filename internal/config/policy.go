@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Policy 前端相關的 CORS / cookie / 登出導向設定（config.yaml 的 policy 區塊）
+type Policy struct {
+	CORS      CORSPolicy     `yaml:"cors"`
+	Cookie    CookiePolicies `yaml:"cookie"`
+	Redirects RedirectPolicy `yaml:"redirects"`
+}
+
+type CORSPolicy struct {
+	// AllowedOrigins 支援子網域萬用字元，例如 https://*.example.com；"*" 代表全部（不可搭配 credentials）
+	AllowedOrigins   []string `yaml:"allowedOrigins"`
+	AllowCredentials bool     `yaml:"allowCredentials"`
+	AllowedMethods   []string `yaml:"allowedMethods"`
+	AllowedHeaders   []string `yaml:"allowedHeaders"`
+	MaxAge           int      `yaml:"maxAge"` // 秒，preflight 快取時間
+}
+
+type CookiePolicy struct {
+	SameSite string `yaml:"sameSite"` // strict / lax / none
+	Secure   *bool  `yaml:"secure"`
+	Domain   string `yaml:"domain"`
+}
+
+// CookiePolicies 預設值加上依 APP_ENV 覆寫的設定
+type CookiePolicies struct {
+	CookiePolicy `yaml:",inline"`
+	Environments map[string]CookiePolicy `yaml:"environments"`
+}
+
+type RedirectPolicy struct {
+	PostLogout        string   `yaml:"postLogout"`        // 預設登出導向
+	AllowedPostLogout []string `yaml:"allowedPostLogout"` // ?redirect= 允許的網址，支援子網域萬用字元
+}
+
+// DefaultPolicy 與原本寫死的行為一致
+func DefaultPolicy() Policy {
+	return Policy{
+		CORS: CORSPolicy{
+			AllowedOrigins:   []string{"http://localhost:3000"},
+			AllowCredentials: true,
+			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Accept", "Content-Type", "X-CSRF-Token", "Authorization", "Idempotency-Key"},
+		},
+		Cookie: CookiePolicies{
+			CookiePolicy: CookiePolicy{SameSite: "strict"},
+		},
+		Redirects: RedirectPolicy{
+			PostLogout: "http://localhost:3000/login",
+		},
+	}
+}
+
+func (p Policy) Validate() error {
+	for _, o := range p.CORS.AllowedOrigins {
+		if o == "*" {
+			if p.CORS.AllowCredentials {
+				return fmt.Errorf("cors: wildcard origin \"*\" cannot be used with allowCredentials")
+			}
+			continue
+		}
+		if _, err := parsePattern(o); err != nil {
+			return fmt.Errorf("cors: %w", err)
+		}
+	}
+
+	cookies := map[string]CookiePolicy{"default": p.Cookie.CookiePolicy}
+	for env, c := range p.Cookie.Environments {
+		cookies[env] = c
+	}
+	for env, c := range cookies {
+		c = p.Cookie.resolve(c)
+		if _, err := ParseSameSite(c.SameSite); err != nil {
+			return fmt.Errorf("cookie %s: %w", env, err)
+		}
+		if c.SameSite == "none" && !*c.Secure {
+			return fmt.Errorf("cookie %s: sameSite none requires secure", env)
+		}
+	}
+
+	if p.Redirects.PostLogout == "" {
+		return fmt.Errorf("redirects: postLogout is required")
+	}
+	for _, r := range p.Redirects.AllowedPostLogout {
+		if _, err := parsePattern(r); err != nil {
+			return fmt.Errorf("redirects: %w", err)
+		}
+	}
+	return nil
+}
+
+// CookieFor 回傳指定環境的 cookie 設定（未覆寫的欄位沿用預設）
+func (c CookiePolicies) CookieFor(env string) CookiePolicy {
+	if override, ok := c.Environments[env]; ok {
+		return c.resolve(override)
+	}
+	return c.resolve(c.CookiePolicy)
+}
+
+func (c CookiePolicies) resolve(p CookiePolicy) CookiePolicy {
+	if p.SameSite == "" {
+		p.SameSite = c.SameSite
+	}
+	if p.SameSite == "" {
+		p.SameSite = "strict"
+	}
+	if p.Secure == nil {
+		p.Secure = c.Secure
+	}
+	if p.Secure == nil {
+		secure := true
+		p.Secure = &secure
+	}
+	if p.Domain == "" {
+		p.Domain = c.Domain
+	}
+	return p
+}
+
+// ParseSameSite 將 strict / lax / none 轉成 http.SameSite
+func ParseSameSite(s string) (http.SameSite, error) {
+	switch strings.ToLower(s) {
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, fmt.Errorf("invalid sameSite: %s", s)
+}
+
+// originPattern scheme://host[:port]，host 可用 *. 開頭代表任意子網域
+type originPattern struct {
+	scheme   string
+	host     string
+	port     string
+	wildcard bool
+	path     string
+}
+
+func parsePattern(s string) (originPattern, error) {
+	u, err := url.Parse(strings.Replace(s, "*.", "wildcard.", 1))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return originPattern{}, fmt.Errorf("invalid origin pattern: %s", s)
+	}
+	p := originPattern{scheme: u.Scheme, host: strings.ToLower(u.Hostname()), port: u.Port(), path: u.Path}
+	if strings.Contains(s, "*.") {
+		if !strings.HasPrefix(p.host, "wildcard.") {
+			return originPattern{}, fmt.Errorf("wildcard must be the leftmost label: %s", s)
+		}
+		p.wildcard = true
+		p.host = strings.TrimPrefix(p.host, "wildcard")
+	}
+	return p, nil
+}
+
+// match 比對 scheme / host / port；萬用字元只比對子網域（不含本身）
+func (p originPattern) match(u *url.URL) bool {
+	if u.Scheme != p.scheme || u.Port() != p.port {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if p.wildcard {
+		return strings.HasSuffix(host, p.host) && len(host) > len(p.host)
+	}
+	return host == p.host
+}
+
+func matchAny(patterns []string, u *url.URL) bool {
+	for _, s := range patterns {
+		p, err := parsePattern(s)
+		if err == nil && p.match(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowOrigin 回傳要寫入 Access-Control-Allow-Origin 的值，不允許時回傳空字串
+func (c CORSPolicy) AllowOrigin(origin string) string {
+	if origin == "" {
+		return ""
+	}
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			return "*"
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Path != "" {
+		return ""
+	}
+	if matchAny(c.AllowedOrigins, u) {
+		return origin
+	}
+	return ""
+}
+
+// PostLogoutRedirect 驗證 ?redirect= 是否在允許清單內（需完全相同的路徑），否則回傳預設網址
+func (r RedirectPolicy) PostLogoutRedirect(requested string) string {
+	if requested == "" {
+		return r.PostLogout
+	}
+	u, err := url.Parse(requested)
+	if err != nil || u.User != nil {
+		return r.PostLogout
+	}
+	for _, s := range r.AllowedPostLogout {
+		p, err := parsePattern(s)
+		if err == nil && p.match(u) && (p.path == "" || p.path == u.Path) {
+			return requested
+		}
+	}
+	return r.PostLogout
+}
+
+// MaxAgeHeader 回傳 Access-Control-Max-Age 的值，未設定時為空字串
+func (c CORSPolicy) MaxAgeHeader() string {
+	if c.MaxAge <= 0 {
+		return ""
+	}
+	return strconv.Itoa(c.MaxAge)
+}
@@ -0,0 +1,63 @@
+package config
+
+import "testing"
+
+func TestCORSAllowOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    string
+	}{
+		{"any origin", []string{"*"}, "https://evil.test", "*"},
+		{"empty origin", []string{"*"}, "", ""},
+		{"exact", []string{"http://localhost:3000"}, "http://localhost:3000", "http://localhost:3000"},
+		{"exact other port", []string{"http://localhost:3000"}, "http://localhost:3001", ""},
+		{"exact missing port", []string{"http://localhost:3000"}, "http://localhost", ""},
+		{"exact other scheme", []string{"https://app.example.com"}, "http://app.example.com", ""},
+		{"host case insensitive", []string{"https://app.example.com"}, "https://APP.example.com", "https://APP.example.com"},
+		{"wildcard subdomain", []string{"https://*.example.com"}, "https://a.example.com", "https://a.example.com"},
+		{"wildcard nested subdomain", []string{"https://*.example.com"}, "https://a.b.example.com", "https://a.b.example.com"},
+		{"wildcard excludes apex", []string{"https://*.example.com"}, "https://example.com", ""},
+		{"wildcard suffix only", []string{"https://*.example.com"}, "https://evilexample.com", ""},
+		{"wildcard other domain", []string{"https://*.example.com"}, "https://a.example.com.evil.test", ""},
+		{"wildcard other scheme", []string{"https://*.example.com"}, "http://a.example.com", ""},
+		{"wildcard with port", []string{"https://*.example.com:8443"}, "https://a.example.com:8443", "https://a.example.com:8443"},
+		{"wildcard port mismatch", []string{"https://*.example.com:8443"}, "https://a.example.com", ""},
+		{"origin with path", []string{"https://*.example.com"}, "https://a.example.com/", ""},
+		{"null origin", []string{"https://*.example.com"}, "null", ""},
+		{"second pattern", []string{"http://localhost:3000", "https://*.example.com"}, "https://a.example.com", "https://a.example.com"},
+		{"invalid pattern skipped", []string{"example.com", "https://*.example.com"}, "https://a.example.com", "https://a.example.com"},
+		{"no patterns", nil, "https://a.example.com", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CORSPolicy{AllowedOrigins: tt.allowed}
+			if got := c.AllowOrigin(tt.origin); got != tt.want {
+				t.Fatalf("AllowOrigin(%q) = %q, want %q", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{"https://example.com", false},
+		{"https://*.example.com", false},
+		{"http://localhost:3000", false},
+		{"example.com", true},
+		{"https://a.*.example.com", true},
+		{"*.example.com", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			_, err := parsePattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePattern(%q) err = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+const redacted = "******"
+
+// Secret 含密碼或金鑰的設定；以 fmt / YAML / JSON 輸出時一律遮蔽，取原值請用 Value
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String 遮蔽密碼：URL 只保留 scheme 與 host，key=value 連線字串只遮 password，其他整串遮蔽
+func (s Secret) String() string {
+	v := string(s)
+	if v == "" {
+		return ""
+	}
+	if u, err := url.Parse(v); err == nil && u.Scheme != "" && u.Host != "" {
+		// RPC URL 的 API key 常放在路徑裡，一併遮蔽
+		return u.Scheme + "://" + u.Host + "/" + redacted
+	}
+	if strings.Contains(v, "=") {
+		fields := strings.Fields(v)
+		for i, f := range fields {
+			if k, _, ok := strings.Cut(f, "="); ok && strings.EqualFold(k, "password") {
+				fields[i] = k + "=" + redacted
+			}
+		}
+		return strings.Join(fields, " ")
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
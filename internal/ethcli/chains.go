@@ -0,0 +1,212 @@
+package ethcli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Chain 鏈的基本資料；TxURL / AddressURL 以 {hash}、{address} 代入
+type Chain struct {
+	ID         uint64   `yaml:"id" json:"id"`
+	Name       string   `yaml:"name" json:"name"`
+	Symbol     string   `yaml:"symbol" json:"symbol"` // 原生幣符號，例如 ETH、POL
+	TxURL      string   `yaml:"txURL" json:"txURL"`
+	AddressURL string   `yaml:"addressURL" json:"addressURL"`
+	RPCURLs    []string `yaml:"rpcURLs" json:"-"` // 依序嘗試，第一個連得上的使用
+	// Confirmations 事件輪詢只處理到 head - confirmations，避免 reorg 後送出已被移除的 log
+	Confirmations uint64 `yaml:"confirmations" json:"confirmations"`
+}
+
+// DefaultChains 內建的鏈資料（不含 RPC URL），設定檔的 chains 可覆寫或新增
+func DefaultChains() []Chain {
+	return []Chain{
+		{ID: 1, Name: "Mainnet", Symbol: "ETH", TxURL: "https://etherscan.io/tx/{hash}", AddressURL: "https://etherscan.io/address/{address}", Confirmations: 12},
+		{ID: 10, Name: "Optimism", Symbol: "ETH", TxURL: "https://optimistic.etherscan.io/tx/{hash}", AddressURL: "https://optimistic.etherscan.io/address/{address}", Confirmations: 12},
+		{ID: 137, Name: "Polygon", Symbol: "POL", TxURL: "https://polygonscan.com/tx/{hash}", AddressURL: "https://polygonscan.com/address/{address}", Confirmations: 64},
+		{ID: 11155111, Name: "Sepolia", Symbol: "ETH", TxURL: "https://sepolia.etherscan.io/tx/{hash}", AddressURL: "https://sepolia.etherscan.io/address/{address}", Confirmations: 3},
+	}
+}
+
+// MergeChains 以 override 覆寫 base 中相同 id 的鏈（空白欄位沿用 base），回傳依 id 排序的結果
+func MergeChains(base, override []Chain) []Chain {
+	byID := map[uint64]Chain{}
+	for _, c := range base {
+		byID[c.ID] = c
+	}
+	for _, o := range override {
+		c, ok := byID[o.ID]
+		if !ok {
+			byID[o.ID] = o
+			continue
+		}
+		if o.Name != "" {
+			c.Name = o.Name
+		}
+		if o.Symbol != "" {
+			c.Symbol = o.Symbol
+		}
+		if o.TxURL != "" {
+			c.TxURL = o.TxURL
+		}
+		if o.AddressURL != "" {
+			c.AddressURL = o.AddressURL
+		}
+		if len(o.RPCURLs) > 0 {
+			c.RPCURLs = o.RPCURLs
+		}
+		if o.Confirmations > 0 {
+			c.Confirmations = o.Confirmations
+		}
+		byID[o.ID] = c
+	}
+
+	out := make([]Chain, 0, len(byID))
+	for _, c := range byID {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Validate 檢查鏈資料格式
+func (c Chain) Validate() error {
+	if c.ID == 0 {
+		return fmt.Errorf("id is required")
+	}
+	if c.Name == "" {
+		return fmt.Errorf("chain %d: name is required", c.ID)
+	}
+	if c.Symbol == "" {
+		return fmt.Errorf("chain %d: symbol is required", c.ID)
+	}
+	if c.TxURL != "" && !strings.Contains(c.TxURL, "{hash}") {
+		return fmt.Errorf("chain %d: txURL must contain {hash}", c.ID)
+	}
+	if c.AddressURL != "" && !strings.Contains(c.AddressURL, "{address}") {
+		return fmt.Errorf("chain %d: addressURL must contain {address}", c.ID)
+	}
+	return nil
+}
+
+// TxLink 交易的區塊瀏覽器網址，未設定時回傳空字串
+func (c Chain) TxLink(hash string) string {
+	if c.TxURL == "" {
+		return ""
+	}
+	return strings.ReplaceAll(c.TxURL, "{hash}", hash)
+}
+
+// AddressLink 地址的區塊瀏覽器網址，未設定時回傳空字串
+func (c Chain) AddressLink(addr string) string {
+	if c.AddressURL == "" {
+		return ""
+	}
+	return strings.ReplaceAll(c.AddressURL, "{address}", addr)
+}
+
+// Chains 同時連線多條鏈，每條鏈一個 Client
+type Chains struct {
+	def     uint64
+	ids     []uint64
+	clients map[uint64]*Client
+}
+
+// DialChains 連線所有有 RPC URL 的鏈；預設鏈一定要連得上，其他鏈失敗只記錄 log
+func DialChains(ctx context.Context, chains []Chain, def uint64) (*Chains, error) {
+	cs := &Chains{def: def, clients: map[uint64]*Client{}}
+	for _, chain := range chains {
+		if len(chain.RPCURLs) == 0 {
+			continue
+		}
+		c, err := Dial(ctx, chain)
+		if err != nil {
+			if chain.ID == def {
+				cs.Close()
+				return nil, err
+			}
+			slog.WarnContext(ctx, "[ethcli] skip chain", "chain_id", chain.ID, "chain", chain.Name, "err", err)
+			continue
+		}
+		cs.ids = append(cs.ids, chain.ID)
+		cs.clients[chain.ID] = c
+	}
+	if _, ok := cs.clients[def]; !ok {
+		cs.Close()
+		return nil, fmt.Errorf("default chain %d has no rpc url", def)
+	}
+	return cs, nil
+}
+
+// Default 預設鏈的 Client
+func (cs *Chains) Default() *Client {
+	return cs.clients[cs.def]
+}
+
+// Get 依 chain id 取得 Client；0 代表預設鏈
+func (cs *Chains) Get(id uint64) (*Client, error) {
+	if id == 0 {
+		id = cs.def
+	}
+	c, ok := cs.clients[id]
+	if !ok {
+		return nil, fmt.Errorf("chain %d is not connected", id)
+	}
+	return c, nil
+}
+
+// Lookup 依 chain id 或名稱（不分大小寫）取得 Client；空字串代表預設鏈
+func (cs *Chains) Lookup(key string) (*Client, error) {
+	if key == "" {
+		return cs.Default(), nil
+	}
+	if id, err := strconv.ParseUint(key, 10, 64); err == nil {
+		return cs.Get(id)
+	}
+	for _, id := range cs.ids {
+		if strings.EqualFold(cs.clients[id].chain.Name, key) {
+			return cs.clients[id], nil
+		}
+	}
+	return nil, fmt.Errorf("chain %s is not connected", key)
+}
+
+// All 依 chain id 排序回傳所有已連線的 Client
+func (cs *Chains) All() []*Client {
+	all := make([]*Client, 0, len(cs.ids))
+	for _, id := range cs.ids {
+		all = append(all, cs.clients[id])
+	}
+	return all
+}
+
+// Heads 各已連線鏈（chain id）目前的區塊高度
+func (cs *Chains) Heads(ctx context.Context) (map[uint64]uint64, error) {
+	heads := make(map[uint64]uint64, len(cs.ids))
+	for _, id := range cs.ids {
+		n, err := cs.clients[id].Backend().BlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("chain %d: get block number: %w", id, err)
+		}
+		heads[id] = n
+	}
+	return heads, nil
+}
+
+// SetSigner 所有鏈使用同一個簽章來源
+func (cs *Chains) SetSigner(s Signer) {
+	for _, id := range cs.ids {
+		cs.clients[id].SetSigner(s)
+	}
+}
+
+// Close 關閉所有連線
+func (cs *Chains) Close() error {
+	for _, c := range cs.clients {
+		c.Close()
+	}
+	return nil
+}
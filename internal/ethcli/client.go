@@ -2,76 +2,103 @@ package ethcli
 
 import (
 	"context"
-	"crypto/ecdsa"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	gethcommon "github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-// Client 封裝 geth ethclient.Client
+// Backend Client 使用的節點 RPC；*ethclient.Client 即符合，測試可換成假的實作
+type Backend interface {
+	bind.ContractBackend
+	ChainID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	BalanceAt(ctx context.Context, account gethcommon.Address, blockNumber *big.Int) (*big.Int, error)
+	TransactionByHash(ctx context.Context, hash gethcommon.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, txHash gethcommon.Hash) (*types.Receipt, error)
+	Close()
+}
+
+var _ Backend = (*ethclient.Client)(nil)
+
+// Client 封裝單一條鏈的 geth ethclient.Client
 type Client struct {
-	rpcURL  string
-	backend *ethclient.Client
+	chain   Chain
+	backend Backend
 
-	mu      sync.RWMutex
-	privKey *ecdsa.PrivateKey
-	from    gethcommon.Address
-	chainID *big.Int
-	network string
+	mu     sync.RWMutex
+	signer Signer
+
+	txMu sync.Mutex // 序列化送交易（取 nonce 到送出），避免同一條鏈上的交易搶同一個 nonce
 }
 
-// New 只建立連線（唯讀）；之後可用 UseSigner 設定/切換錢包
-func New(rpcURL string) (*Client, error) {
-	if rpcURL == "" {
-		return nil, fmt.Errorf("rpcURL is empty")
-	}
-	// ethclient.DialContext 會在 ctx 取消時中止連線嘗試
-	backend, err := ethclient.DialContext(context.Background(), rpcURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to dial rpc: %w", err)
-	}
-	log.Printf("[ethcli] connected to %s", rpcURL)
-	return &Client{rpcURL: rpcURL, backend: backend}, nil
+// Dial 依序嘗試 chain 的 RPC URL 建立連線（唯讀），並確認節點的 chain id 一致；之後可用 UseSigner 設定/切換錢包
+func Dial(ctx context.Context, chain Chain) (*Client, error) {
+	if len(chain.RPCURLs) == 0 {
+		return nil, fmt.Errorf("chain %d: rpcURLs is empty", chain.ID)
+	}
+	var errs []error
+	for i, rpcURL := range chain.RPCURLs {
+		backend, err := dialChain(ctx, rpcURL, chain)
+		if err != nil {
+			// 不記錄 URL 本身，避免洩漏 API key
+			errs = append(errs, fmt.Errorf("rpc #%d: %w", i+1, err))
+			continue
+		}
+		slog.InfoContext(ctx, "[ethcli] connected", "chain", chain.Name, "chain_id", chain.ID, "rpc", i+1)
+		return &Client{chain: chain, backend: backend}, nil
+	}
+	return nil, fmt.Errorf("chain %d (%s): %w", chain.ID, chain.Name, errors.Join(errs...))
 }
 
-// UseSigner 以新的私鑰切換目前 signer（thread-safe）
-func (c *Client) UseSigner(req UseSignerRequest) error {
-	if strings.TrimSpace(req.PrivateKey) == "" {
-		return fmt.Errorf("privKeyHex is empty")
-	}
-	pk, err := crypto.HexToECDSA(trim0x(req.PrivateKey))
+func dialChain(ctx context.Context, rpcURL string, chain Chain) (*ethclient.Client, error) {
+	// 會在 ctx 取消時中止連線嘗試
+	backend, err := dialRPC(ctx, rpcURL, chain.Name)
 	if err != nil {
-		return fmt.Errorf("invalid private key: %w", err)
+		return nil, fmt.Errorf("failed to dial rpc: %w", err)
 	}
-
-	// 先查 network/chainID（不鎖；讀 backend thread-safe）
-	chainID, err := c.backend.NetworkID(context.Background())
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	id, err := backend.ChainID(ctx)
 	if err != nil {
-		return fmt.Errorf("get chainID: %w", err)
+		backend.Close()
+		return nil, fmt.Errorf("get chainID: %w", err)
 	}
+	if id.Uint64() != chain.ID {
+		backend.Close()
+		return nil, fmt.Errorf("rpc reports chain %s", id)
+	}
+	return backend, nil
+}
 
-	network := networkName(chainID)
-
-	// 寫入受保護欄位
+// SetSigner 設定/切換簽章來源（thread-safe）
+func (c *Client) SetSigner(s Signer) {
 	c.mu.Lock()
-	c.privKey = pk
-	c.from = crypto.PubkeyToAddress(pk.PublicKey)
-	c.chainID = new(big.Int).Set(chainID)
-	c.network = network
+	c.signer = s
 	c.mu.Unlock()
 
-	log.Printf("[ethcli] signer address: %s", c.From().Hex())
-	log.Printf("[ethcli] network chainID: %s (%s)", c.ChainID().String(), c.Network())
-	return nil
+	slog.Info("[ethcli] signer set", "chain", c.chain.Name, "address", s.Address().Hex())
+}
+
+// Signer 目前的簽章來源，未設定時為 nil
+func (c *Client) Signer() Signer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.signer
 }
 
+// LockTx / UnlockTx 送交易前後呼叫；合約交易與 TransferETH 共用同一把鎖
+func (c *Client) LockTx()   { c.txMu.Lock() }
+func (c *Client) UnlockTx() { c.txMu.Unlock() }
+
 // Close 關閉底層連線
 func (c *Client) Close() error {
 	if c.backend != nil {
@@ -80,55 +107,62 @@ func (c *Client) Close() error {
 	return nil
 }
 
-func (c *Client) Backend() *ethclient.Client       { return c.backend }
+func (c *Client) Backend() Backend                 { return c.backend }
 func (c *Client) ConBackend() bind.ContractBackend { return c.backend }
 
 func (c *Client) HasSigner() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.privKey != nil && c.chainID != nil
+	return c.signer != nil
 }
 
 func (c *Client) From() gethcommon.Address {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.from
+	if c.signer == nil {
+		return gethcommon.Address{}
+	}
+	return c.signer.Address()
+}
+
+// Chain 回傳連線的鏈資料
+func (c *Client) Chain() Chain {
+	return c.chain
 }
 
 func (c *Client) ChainID() *big.Int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.chainID == nil {
-		return nil
-	}
-	return new(big.Int).Set(c.chainID)
+	return new(big.Int).SetUint64(c.chain.ID)
 }
 
 func (c *Client) Network() string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.network
+	return c.chain.Name
 }
 
 func (c *Client) IsTxHex(s string) bool {
 	return strings.HasPrefix(s, "0x") && len(s) == 66
 }
 
-// NewTransactor 依目前 signer 產生帶 context 的 TransactOpts
+// NewTransactor 依目前 signer 產生帶 context 的 TransactOpts，簽章透過 Signer 進行
 func (c *Client) NewTransactor(ctx context.Context) (*bind.TransactOpts, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	if c.privKey == nil || c.chainID == nil {
-		return nil, fmt.Errorf("client has no signer")
+	signer := c.Signer()
+	if signer == nil {
+		return nil, ErrNoSigner
+	}
+	from := signer.Address()
+	chainID := c.ChainID()
+	opts := &bind.TransactOpts{
+		From: from,
+		Signer: func(addr gethcommon.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if addr != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(ctx, tx, chainID)
+		},
+		Context: ctx,
 	}
-	opts, err := bind.NewKeyedTransactorWithChainID(c.privKey, c.chainID)
-	if err != nil {
-		return nil, err
-	}
-	opts.Context = ctx
 
 	// 查 nonce
-	nonce, err := c.backend.PendingNonceAt(ctx, c.from)
+	nonce, err := c.backend.PendingNonceAt(ctx, from)
 	if err != nil {
 		return nil, err
 	}
@@ -167,12 +201,12 @@ func WeiToEtherString(wei *big.Int) string {
 
 func AmountToWei(amountEthStr string) (*big.Int, error) {
 	if strings.TrimSpace(amountEthStr) == "" {
-		return nil, fmt.Errorf("amount_ether is required")
+		return nil, invalidArgument("amount_ether is required")
 	}
 	oneEthWei := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
 	r, ok := new(big.Rat).SetString(amountEthStr)
 	if !ok {
-		return nil, fmt.Errorf("invalid amount_ether")
+		return nil, invalidArgument("invalid amount_ether")
 	}
 	weiRat := new(big.Rat).Mul(r, new(big.Rat).SetInt(oneEthWei))
 	wei := new(big.Int)
@@ -189,18 +223,3 @@ func trim0x(s string) string {
 	}
 	return s
 }
-
-func networkName(id *big.Int) string {
-	switch id.Uint64() {
-	case 1:
-		return "Mainnet"
-	case 10:
-		return "Optimism"
-	case 137:
-		return "Polygon"
-	case 11155111:
-		return "Sepolia"
-	default:
-		return "unknown"
-	}
-}
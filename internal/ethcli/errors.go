@@ -0,0 +1,17 @@
+package ethcli
+
+import "fmt"
+
+// argumentError 呼叫端給的參數不正確（地址、金額），HTTP 層回 400
+type argumentError struct {
+	msg string
+}
+
+func invalidArgument(format string, args ...interface{}) error {
+	return &argumentError{msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *argumentError) Error() string { return e.msg }
+
+// InvalidArgument 供 httpapi 辨識為 400
+func (e *argumentError) InvalidArgument() bool { return true }
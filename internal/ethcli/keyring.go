@@ -0,0 +1,74 @@
+package ethcli
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Keyring 預先註冊的 signer，API 只以 id 切換，私鑰不經過 HTTP
+type Keyring struct {
+	ids     []string // 註冊順序，第一個為預設 signer
+	signers map[string]Signer
+
+	mu     sync.RWMutex
+	active string
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{signers: map[string]Signer{}}
+}
+
+// Add 註冊 signer，id 不可重複
+func (k *Keyring) Add(id string, s Signer) error {
+	if _, ok := k.signers[id]; ok {
+		return fmt.Errorf("signer %s already registered", id)
+	}
+	k.ids = append(k.ids, id)
+	k.signers[id] = s
+	if k.active == "" {
+		k.active = id
+	}
+	return nil
+}
+
+// Get 依 id 取得 signer
+func (k *Keyring) Get(id string) (Signer, error) {
+	s, ok := k.signers[id]
+	if !ok {
+		return nil, fmt.Errorf("signer %s not found", id)
+	}
+	return s, nil
+}
+
+// Use 將 id 設為使用中的 signer 並回傳
+func (k *Keyring) Use(id string) (Signer, error) {
+	s, err := k.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	k.mu.Lock()
+	k.active = id
+	k.mu.Unlock()
+	return s, nil
+}
+
+// Active 使用中的 signer id（預設為第一個註冊的）
+func (k *Keyring) Active() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+// IDs 依註冊順序回傳所有 id
+func (k *Keyring) IDs() []string {
+	return append([]string(nil), k.ids...)
+}
+
+// Close 關閉需要連線的 signer（例如 Clef）
+func (k *Keyring) Close() {
+	for _, s := range k.signers {
+		if c, ok := s.(*ClefSigner); ok {
+			c.Close()
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package ethcli
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Signer 交易簽章；私鑰可以在記憶體、keystore 檔案或遠端 signer 裡
+type Signer interface {
+	Address() gethcommon.Address
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// KeySigner 以記憶體中的私鑰簽章
+type KeySigner struct {
+	key  *ecdsa.PrivateKey
+	addr gethcommon.Address
+}
+
+// NewKeySigner 由 hex 私鑰（可帶 0x）建立 signer
+func NewKeySigner(hexKey string) (*KeySigner, error) {
+	if strings.TrimSpace(hexKey) == "" {
+		return nil, fmt.Errorf("private key is empty")
+	}
+	pk, err := crypto.HexToECDSA(trim0x(strings.TrimSpace(hexKey)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return &KeySigner{key: pk, addr: crypto.PubkeyToAddress(pk.PublicKey)}, nil
+}
+
+// NewKeystoreSigner 以密碼解開 go-ethereum keystore JSON 檔，解開後的私鑰只存在記憶體
+func NewKeystoreSigner(path, password string) (*KeySigner, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore: %w", err)
+	}
+	key, err := keystore.DecryptKey(b, password)
+	if err != nil {
+		return nil, fmt.Errorf("unlock keystore: %w", err)
+	}
+	return &KeySigner{key: key.PrivateKey, addr: key.Address}, nil
+}
+
+func (s *KeySigner) Address() gethcommon.Address { return s.addr }
+
+func (s *KeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// ClefSigner 透過 Clef 相容的 JSON-RPC（account_list / account_signTransaction）簽章，私鑰不進入本服務
+type ClefSigner struct {
+	client *rpc.Client
+	addr   gethcommon.Address
+}
+
+// NewClefSigner 連線遠端 signer；address 為空時使用 account_list 的第一個帳號
+func NewClefSigner(ctx context.Context, endpoint, address string) (*ClefSigner, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("dial signer: %w", err)
+	}
+	s := &ClefSigner{client: client}
+
+	var accounts []gethcommon.Address
+	if err := client.CallContext(ctx, &accounts, "account_list"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("account_list: %w", err)
+	}
+	if address == "" {
+		if len(accounts) == 0 {
+			client.Close()
+			return nil, fmt.Errorf("signer has no accounts")
+		}
+		s.addr = accounts[0]
+		return s, nil
+	}
+	if !gethcommon.IsHexAddress(address) {
+		client.Close()
+		return nil, fmt.Errorf("invalid signer address")
+	}
+	s.addr = gethcommon.HexToAddress(address)
+	for _, a := range accounts {
+		if a == s.addr {
+			return s, nil
+		}
+	}
+	client.Close()
+	return nil, fmt.Errorf("signer does not manage %s", s.addr.Hex())
+}
+
+func (s *ClefSigner) Address() gethcommon.Address { return s.addr }
+
+// clefTxArgs account_signTransaction 的參數（與 Clef 的 SendTxArgs 相同欄位）
+type clefTxArgs struct {
+	From                 gethcommon.MixedcaseAddress  `json:"from"`
+	To                   *gethcommon.MixedcaseAddress `json:"to"`
+	Gas                  hexutil.Uint64               `json:"gas"`
+	MaxFeePerGas         *hexutil.Big                 `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big                 `json:"maxPriorityFeePerGas,omitempty"`
+	GasPrice             *hexutil.Big                 `json:"gasPrice,omitempty"`
+	Value                hexutil.Big                  `json:"value"`
+	Nonce                hexutil.Uint64               `json:"nonce"`
+	Input                hexutil.Bytes                `json:"input"`
+	ChainID              *hexutil.Big                 `json:"chainId,omitempty"`
+	AccessList           *types.AccessList            `json:"accessList,omitempty"`
+}
+
+func (s *ClefSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := clefTxArgs{
+		From:    gethcommon.NewMixedcaseAddress(s.addr),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		to := gethcommon.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	default:
+		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
+	}
+
+	var res struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("account_signTransaction: %w", err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, fmt.Errorf("decode signed tx: %w", err)
+	}
+	// 確認遠端簽出來的是同一筆交易、同一個帳號
+	signer := types.LatestSignerForChainID(chainID)
+	if signed.Type() != tx.Type() || signer.Hash(signed) != signer.Hash(tx) {
+		return nil, fmt.Errorf("signer returned a different transaction")
+	}
+	from, err := types.Sender(signer, signed)
+	if err != nil || from != s.addr {
+		return nil, fmt.Errorf("signer returned a transaction from another account")
+	}
+	return signed, nil
+}
+
+// Close 關閉遠端連線
+func (s *ClefSigner) Close() {
+	s.client.Close()
+}
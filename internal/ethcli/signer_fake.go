@@ -0,0 +1,92 @@
+package ethcli
+
+import (
+	"context"
+	"fmt"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// NewClefStandIn 以 KeySigner 模擬 Clef 的 account_list / account_signTransaction，
+// 讓 ClefSigner 不需要真的 Clef 即可測試，例如：
+//
+//	srv := httptest.NewServer(ethcli.NewClefStandIn(key))
+//	s, _ := ethcli.NewClefSigner(ctx, srv.URL, "")
+func NewClefStandIn(key *KeySigner) *rpc.Server {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("account", &clefStandIn{key: key}); err != nil {
+		panic(err)
+	}
+	return srv
+}
+
+type clefStandIn struct {
+	key *KeySigner
+}
+
+func (c *clefStandIn) List() []gethcommon.Address {
+	return []gethcommon.Address{c.key.Address()}
+}
+
+type clefSignResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+func (c *clefStandIn) SignTransaction(ctx context.Context, args clefTxArgs) (*clefSignResult, error) {
+	if args.From.Address() != c.key.Address() {
+		return nil, fmt.Errorf("unknown account %s", args.From.Address().Hex())
+	}
+	if args.ChainID == nil {
+		return nil, fmt.Errorf("chainId is required")
+	}
+	var to *gethcommon.Address
+	if args.To != nil {
+		addr := args.To.Address()
+		to = &addr
+	}
+
+	var tx *types.Transaction
+	if args.GasPrice != nil {
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(args.Nonce),
+			GasPrice: args.GasPrice.ToInt(),
+			Gas:      uint64(args.Gas),
+			To:       to,
+			Value:    args.Value.ToInt(),
+			Data:     args.Input,
+		})
+	} else {
+		if args.MaxFeePerGas == nil || args.MaxPriorityFeePerGas == nil {
+			return nil, fmt.Errorf("maxFeePerGas and maxPriorityFeePerGas are required")
+		}
+		var accessList types.AccessList
+		if args.AccessList != nil {
+			accessList = *args.AccessList
+		}
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:    args.ChainID.ToInt(),
+			Nonce:      uint64(args.Nonce),
+			GasTipCap:  args.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap:  args.MaxFeePerGas.ToInt(),
+			Gas:        uint64(args.Gas),
+			To:         to,
+			Value:      args.Value.ToInt(),
+			Data:       args.Input,
+			AccessList: accessList,
+		})
+	}
+
+	signed, err := c.key.SignTx(ctx, tx, args.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &clefSignResult{Raw: raw, Tx: signed}, nil
+}
@@ -0,0 +1,78 @@
+package ethcli
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestKeySigner(t *testing.T) *KeySigner {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewKeySigner("0x" + hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestClefSigner(t *testing.T) {
+	ctx := context.Background()
+	key := newTestKeySigner(t)
+	srv := httptest.NewServer(NewClefStandIn(key))
+	defer srv.Close()
+
+	s, err := NewClefSigner(ctx, srv.URL, "")
+	if err != nil {
+		t.Fatalf("new clef signer: %v", err)
+	}
+	defer s.Close()
+	if s.Address() != key.Address() {
+		t.Fatalf("address = %s, want %s", s.Address().Hex(), key.Address().Hex())
+	}
+
+	chainID := big.NewInt(11155111)
+	to := gethcommon.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tests := []struct {
+		name string
+		tx   *types.Transaction
+	}{
+		{"dynamic fee", types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &to, Value: big.NewInt(1e15)})},
+		{"legacy", types.NewTx(&types.LegacyTx{Nonce: 4, GasPrice: big.NewInt(2), Gas: 50000, To: &to, Data: []byte{0x12, 0x34}})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := s.SignTx(ctx, tt.tx, chainID)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+			signer := types.LatestSignerForChainID(chainID)
+			if signer.Hash(signed) != signer.Hash(tt.tx) {
+				t.Errorf("signed a different transaction")
+			}
+			if from, err := types.Sender(signer, signed); err != nil || from != key.Address() {
+				t.Errorf("sender = %s (%v), want %s", from.Hex(), err, key.Address().Hex())
+			}
+		})
+	}
+}
+
+func TestClefSignerUnknownAccount(t *testing.T) {
+	srv := httptest.NewServer(NewClefStandIn(newTestKeySigner(t)))
+	defer srv.Close()
+
+	_, err := NewClefSigner(context.Background(), srv.URL, "0x00000000000000000000000000000000000000bb")
+	if err == nil || !strings.Contains(err.Error(), "does not manage") {
+		t.Fatalf("err = %v, want signer does not manage", err)
+	}
+}
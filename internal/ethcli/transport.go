@@ -0,0 +1,148 @@
+package ethcli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/wkchen007/nftweb-back/internal/metrics"
+	"github.com/wkchen007/nftweb-back/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// dialRPC 建立 ethclient；HTTP(S) 的 RPC 經由 rpcTransport 記錄每個 JSON-RPC method 的次數、延遲與錯誤，
+// 並為每次呼叫建立 span（WebSocket / IPC 不經過 http.Client，因此沒有 RPC 指標與 span）
+func dialRPC(ctx context.Context, rpcURL, chain string) (*ethclient.Client, error) {
+	if !strings.HasPrefix(rpcURL, "http://") && !strings.HasPrefix(rpcURL, "https://") {
+		return ethclient.DialContext(ctx, rpcURL)
+	}
+	hc := &http.Client{Transport: &rpcTransport{chain: chain, next: http.DefaultTransport}}
+	c, err := rpc.DialOptions(ctx, rpcURL, rpc.WithHTTPClient(hc))
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(c), nil
+}
+
+// rpcTransport 解析 JSON-RPC 請求（含 batch）的 method 與回應中的 error
+type rpcTransport struct {
+	chain string
+	next  http.RoundTripper
+}
+
+type rpcMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Error  json.RawMessage `json:"error"`
+}
+
+func (t *rpcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return t.next.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+
+	calls := decodeRPC(body)
+	for _, c := range calls {
+		metrics.RPCCalls.WithLabelValues(t.chain, c.Method).Inc()
+	}
+
+	ctx, span := tracing.Start(req.Context(), spanName(calls), trace.WithSpanKind(trace.SpanKindClient))
+	span.SetAttributes(attribute.String("rpc.system", "jsonrpc"), attribute.String("eth.chain", t.chain))
+	if len(calls) == 1 {
+		span.SetAttributes(attribute.String("rpc.method", calls[0].Method))
+	}
+	out = out.WithContext(ctx)
+	// 結束時把下面記下的錯誤寫進 span
+	var spanErr error
+	defer func() { tracing.End(span, spanErr) }()
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(out)
+	elapsed := time.Since(start).Seconds()
+	for _, c := range calls {
+		metrics.RPCDuration.WithLabelValues(t.chain, c.Method).Observe(elapsed)
+	}
+
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		t.countErrors(calls, nil)
+		spanErr = err
+		if err == nil {
+			spanErr = fmt.Errorf("http status %d", resp.StatusCode)
+		}
+		return resp, err
+	}
+
+	// 讀出回應找 JSON-RPC error，再放回給 rpc client
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.countErrors(calls, nil)
+		spanErr = err
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	failed := map[string]bool{}
+	for _, m := range decodeRPC(respBody) {
+		if len(m.Error) > 0 && string(m.Error) != "null" {
+			failed[string(m.ID)] = true
+		}
+	}
+	if len(failed) > 0 {
+		t.countErrors(calls, failed)
+		spanErr = fmt.Errorf("%d json-rpc error(s)", len(failed))
+	}
+	return resp, nil
+}
+
+// spanName 單一呼叫以 method 命名（例如 eth_estimateGas），batch 另外標示
+func spanName(calls []rpcMessage) string {
+	if len(calls) == 1 {
+		return calls[0].Method
+	}
+	return fmt.Sprintf("jsonrpc batch (%d)", len(calls))
+}
+
+// countErrors failed 為 nil 時所有呼叫都算失敗，否則只算 id 在 failed 中的
+func (t *rpcTransport) countErrors(calls []rpcMessage, failed map[string]bool) {
+	for _, c := range calls {
+		if failed == nil || failed[string(c.ID)] {
+			metrics.RPCErrors.WithLabelValues(t.chain, c.Method).Inc()
+		}
+	}
+}
+
+// decodeRPC 解析單一或 batch 的 JSON-RPC 訊息，格式不符時回傳 nil
+func decodeRPC(b []byte) []rpcMessage {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil
+	}
+	if b[0] == '[' {
+		var batch []rpcMessage
+		if json.Unmarshal(b, &batch) != nil {
+			return nil
+		}
+		return batch
+	}
+	var m rpcMessage
+	if json.Unmarshal(b, &m) != nil {
+		return nil
+	}
+	return []rpcMessage{m}
+}
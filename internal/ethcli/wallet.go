@@ -2,25 +2,36 @@ package ethcli
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/wkchen007/nftweb-back/internal/idempotency"
+	"github.com/wkchen007/nftweb-back/internal/metrics"
+	"github.com/wkchen007/nftweb-back/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Address struct {
-	Address string `json:"address"`
-	Network string `json:"network"`
+	Address     string `json:"address"`
+	Network     string `json:"network"`
+	ChainID     uint64 `json:"chainId"`
+	ExplorerUrl string `json:"explorerUrl,omitempty"`
 }
 
 type Wallet struct {
 	Address    string `json:"address"`
 	Balance    string `json:"balance"`
 	BalanceEth string `json:"balanceEth"`
+	Symbol     string `json:"symbol"`
 	Network    string `json:"network"`
+	ChainID    uint64 `json:"chainId"`
 }
 
 type TransferRequest struct {
@@ -33,67 +44,90 @@ type TransferResponse struct {
 	To          string `json:"to"`
 	ValueWei    string `json:"valueWei"`
 	ValueEther  string `json:"valueEther"`
+	Symbol      string `json:"symbol"`
 	TxHash      string `json:"txHash"`
 	Network     string `json:"network"`
+	ChainID     uint64 `json:"chainId"`
 	ExplorerUrl string `json:"explorerUrl"`
 }
 
+// UseSignerRequest 以預先註冊的 signer id 切換，不接受私鑰
 type UseSignerRequest struct {
-	PrivateKey string `json:"privateKey"`
+	KeyID string `json:"keyId"`
 }
 
 type UseSignerResponse struct {
+	KeyID   string   `json:"keyId"`
+	Address string   `json:"address"`
+	Chains  []string `json:"chains"`
+}
+
+// SignerInfo 已註冊的 signer（不含任何密鑰）
+type SignerInfo struct {
+	KeyID   string `json:"keyId"`
 	Address string `json:"address"`
-	Network string `json:"network"`
+	Active  bool   `json:"active"`
 }
 
+// ErrNoSigner 尚未設定 signer（需先登入或 useSigner）
+var ErrNoSigner = errors.New("client has no signer")
+
+// BuildTxURL 依鏈設定的瀏覽器網址範本產生交易連結
 func (c *Client) BuildTxURL(hash string) string {
-	base := "https://etherscan.io"
-	switch c.chainID.Int64() {
-	case 1:
-		base = "https://etherscan.io"
-	case 5:
-		base = "https://goerli.etherscan.io"
-	case 11155111:
-		base = "https://sepolia.etherscan.io"
-	case 137:
-		base = "https://polygonscan.com"
-	}
-	return fmt.Sprintf("%s/tx/%s", base, hash)
+	return c.chain.TxLink(hash)
 }
 
 func (c *Client) GetAddress() Address {
-
-	return Address{
-		Address: c.from.Hex(),
-		Network: c.network,
+	from := c.From()
+	addr := Address{
+		Address: from.Hex(),
+		Network: c.chain.Name,
+		ChainID: c.chain.ID,
 	}
+	if c.HasSigner() {
+		addr.ExplorerUrl = c.chain.AddressLink(from.Hex())
+	}
+	return addr
 }
 
-// GetBalance 取得最新區塊的 ETH 餘額（wei）
-func (c *Client) GetBalance() (Wallet, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	balWei, err := c.backend.BalanceAt(ctx, c.from, nil)
+// GetBalance 取得最新區塊的 ETH 餘額（wei），上限由呼叫端的 ctx 決定
+func (c *Client) GetBalance(ctx context.Context) (Wallet, error) {
+	if !c.HasSigner() {
+		return Wallet{}, ErrNoSigner
+	}
+	from := c.From()
+	balWei, err := c.backend.BalanceAt(ctx, from, nil)
 	if err != nil {
 		return Wallet{}, err
 	}
 
 	wallet := Wallet{
-		Address:    c.from.Hex(),
+		Address:    from.Hex(),
 		Balance:    balWei.String(),
 		BalanceEth: WeiToEtherString(balWei),
-		Network:    c.network,
+		Symbol:     c.chain.Symbol,
+		Network:    c.chain.Name,
+		ChainID:    c.chain.ID,
 	}
 
 	return wallet, nil
 }
 
-func (c *Client) TransferETH(req TransferRequest) (TransferResponse, error) {
+// TransferETH 簽章並送出轉帳，上限由呼叫端的 ctx 決定
+func (c *Client) TransferETH(ctx context.Context, req TransferRequest) (res TransferResponse, err error) {
+	ctx, span := tracing.Start(ctx, "ethcli.TransferETH")
+	span.SetAttributes(attribute.String("eth.chain", c.chain.Name))
+	defer func() { tracing.End(span, err) }()
+
+	signer := c.Signer()
+	if signer == nil {
+		return TransferResponse{}, ErrNoSigner
+	}
+	from := signer.Address()
+
 	toStr := strings.TrimSpace(req.To)
 	if !IsHexAddress(toStr) {
-		return TransferResponse{}, fmt.Errorf("invalid 'to' address")
+		return TransferResponse{}, invalidArgument("invalid 'to' address")
 	}
 	to := GethHexToAddress(toStr)
 
@@ -102,16 +136,17 @@ func (c *Client) TransferETH(req TransferRequest) (TransferResponse, error) {
 		return TransferResponse{}, err
 	}
 	if amountWei.Cmp(big.NewInt(0)) <= 0 {
-		return TransferResponse{}, fmt.Errorf("amountEth must be > 0")
+		return TransferResponse{}, invalidArgument("amountEth must be > 0")
 	}
 	/*
 		if c.from == to {
 			return TransferResponse{}, fmt.Errorf("cannot transfer to self")
 		}
 	*/
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	nonce, err := c.backend.PendingNonceAt(ctx, c.from)
+	c.LockTx()
+	defer c.UnlockTx()
+
+	nonce, err := c.backend.PendingNonceAt(ctx, from)
 	if err != nil {
 		return TransferResponse{}, fmt.Errorf("get nonce: %w", err)
 	}
@@ -136,7 +171,7 @@ func (c *Client) TransferETH(req TransferRequest) (TransferResponse, error) {
 	const gasLimit = uint64(21000)
 
 	tx := types.NewTx(&types.DynamicFeeTx{
-		ChainID:   new(big.Int).Set(c.chainID),
+		ChainID:   c.ChainID(),
 		Nonce:     nonce,
 		GasTipCap: tip,
 		GasFeeCap: feeCap,
@@ -146,25 +181,52 @@ func (c *Client) TransferETH(req TransferRequest) (TransferResponse, error) {
 		Data:      nil,
 	})
 
-	signer := types.LatestSignerForChainID(c.chainID)
-	signed, err := types.SignTx(tx, signer, c.privKey)
+	signed, err := signer.SignTx(ctx, tx, c.ChainID())
 	if err != nil {
 		return TransferResponse{}, fmt.Errorf("sign tx: %w", err)
 	}
 
-	if err := c.backend.SendTransaction(ctx, signed); err != nil {
+	idempotency.MarkSent(ctx)
+	err = c.backend.SendTransaction(ctx, signed)
+	metrics.TxSent.WithLabelValues(c.chain.Name, "", "transferETH", metrics.Result(err)).Inc()
+	if err != nil {
 		return TransferResponse{}, fmt.Errorf("send tx: %w", err)
 	}
 
-	log.Printf("[ethcli] sent tx %s: %s wei from %s to %s", signed.Hash().Hex(), amountWei.String(), c.from.Hex(), to.Hex())
+	slog.InfoContext(ctx, "[ethcli] tx sent", "chain", c.chain.Name, "tx", signed.Hash().Hex(), "value_wei", amountWei.String(), "from", from.Hex(), "to", to.Hex())
 
 	return TransferResponse{
-		From:        c.from.Hex(),
+		From:        from.Hex(),
 		To:          to.Hex(),
 		ValueWei:    amountWei.String(),
 		ValueEther:  WeiToEtherString(amountWei),
+		Symbol:      c.chain.Symbol,
 		TxHash:      signed.Hash().Hex(),
-		Network:     c.network,
+		Network:     c.chain.Name,
+		ChainID:     c.chain.ID,
 		ExplorerUrl: c.BuildTxURL(signed.Hash().Hex()),
 	}, nil
 }
+
+// WaitMined 輪詢交易收據直到上鏈或 ctx 結束
+func (c *Client) WaitMined(ctx context.Context, txHash string) (*types.Receipt, error) {
+	hash := gethcommon.HexToHash(txHash)
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := c.backend.TransactionReceipt(ctx, hash)
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,9 +1,16 @@
 package event
 
 import (
-	"log"
+	"context"
+	"log/slog"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/wkchen007/nftweb-back/internal/logging"
+	"github.com/wkchen007/nftweb-back/internal/metrics"
+	"github.com/wkchen007/nftweb-back/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Emitter struct {
@@ -20,22 +27,45 @@ func (e *Emitter) setup() error {
 	return declareExchange(channel)
 }
 
-func (e *Emitter) Push(event string, severity string) error {
+// RequestIDHeader 觸發事件的 HTTP request id
+const RequestIDHeader = "x-request-id"
+
+// Push 發佈到 logs_topic；ctx 中的 trace context 與 request id 會寫進 message headers，consumer 以 ExtractTrace 接續同一個 trace
+func (e *Emitter) Push(ctx context.Context, event string, severity string) (err error) {
+	ctx, span := tracing.Start(ctx, "logs_topic publish", trace.WithSpanKind(trace.SpanKindProducer))
+	span.SetAttributes(
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.destination.name", "logs_topic"),
+		attribute.String("messaging.rabbitmq.destination.routing_key", severity),
+	)
+	defer func() {
+		metrics.AMQPPublish.WithLabelValues("logs_topic", metrics.Result(err)).Inc()
+		tracing.End(span, err)
+	}()
+
 	channel, err := e.connection.Channel()
 	if err != nil {
 		return err
 	}
 	defer channel.Close()
 
-	log.Println("Pushing to channel")
+	slog.DebugContext(ctx, "[event] pushing to channel", "routing_key", severity)
+
+	headers := amqp.Table{}
+	otel.GetTextMapPropagator().Inject(ctx, HeaderCarrier(headers))
+	if id := logging.RequestID(ctx); id != "" {
+		headers[RequestIDHeader] = id
+	}
 
-	err = channel.Publish(
+	err = channel.PublishWithContext(
+		ctx,
 		"logs_topic",
 		severity,
 		false,
 		false,
 		amqp.Publishing{
 			ContentType: "text/plain",
+			Headers:     headers,
 			Body:        []byte(event),
 		},
 	)
@@ -0,0 +1,185 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Code 穩定的錯誤代碼，用戶端應以 code 判斷而不是 message
+type Code string
+
+const (
+	CodeBadRequest            Code = "bad_request"
+	CodeInvalidJSON           Code = "invalid_json"
+	CodeValidation            Code = "validation_failed"
+	CodeUnauthorized          Code = "unauthorized"
+	CodeInvalidCredentials    Code = "invalid_credentials"
+	CodeForbidden             Code = "forbidden"
+	CodeNotFound              Code = "not_found"
+	CodeConflict              Code = "conflict"
+	CodeIdempotencyMismatch   Code = "idempotency_key_reused"
+	CodeIdempotencyInProgress Code = "idempotency_key_in_progress"
+	CodeTooLarge              Code = "request_too_large"
+	CodeRateLimited           Code = "rate_limited"
+	CodeLoginThrottled        Code = "login_throttled"
+	CodeExecutionReverted     Code = "execution_reverted"
+	CodeOverMaxSupply         Code = "over_max_supply"
+	CodeIncorrectPayment      Code = "incorrect_payment"
+	CodeNoFunds               Code = "no_funds"
+	CodeWithdrawFailed        Code = "withdraw_failed"
+	CodeCanceled              Code = "request_canceled"
+	CodeUpstream              Code = "upstream_error"
+	CodeUnavailable           Code = "service_unavailable"
+	CodeTimeout               Code = "timeout"
+	CodeInternal              Code = "internal_error"
+)
+
+// Error 回給用戶端的錯誤；Message 與 Details 會原樣輸出，Err 只寫進 log
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details interface{}
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithDetails 附上額外資訊（例如每列的驗證結果）
+func (e *Error) WithDetails(details interface{}) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// Wrap 附上內部原因，只寫進 log
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+func New(status int, code Code, format string, args ...interface{}) *Error {
+	return &Error{Status: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Invalid 輸入驗證失敗
+func Invalid(format string, args ...interface{}) *Error {
+	return New(http.StatusBadRequest, CodeValidation, format, args...)
+}
+
+func NotFound(format string, args ...interface{}) *Error {
+	return New(http.StatusNotFound, CodeNotFound, format, args...)
+}
+
+func Conflict(format string, args ...interface{}) *Error {
+	return New(http.StatusConflict, CodeConflict, format, args...)
+}
+
+func Unauthorized() *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, "authentication required")
+}
+
+// Internal 未預期的錯誤，用戶端只會看到 internal server error
+func Internal(err error) *Error {
+	if e, ok := typed(err); ok {
+		return e
+	}
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+// Upstream 呼叫 RPC 節點等外部服務失敗時使用，用戶端只會看到 msg；
+// 合約 revert 以 422 回傳，已知的原因對應穩定的 code，原始訊息只寫進 log
+func Upstream(msg string, err error) *Error {
+	if e, ok := typed(err); ok {
+		return e
+	}
+	var rpcErr interface {
+		error
+		ErrorCode() int
+	}
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == revertErrorCode {
+		return reverted(rpcErr.Error()).Wrap(err)
+	}
+	return &Error{Status: http.StatusBadGateway, Code: CodeUpstream, Message: msg, Err: err}
+}
+
+// revertErrorCode eth_call / eth_estimateGas 因合約 revert 失敗時的 JSON-RPC error code
+const revertErrorCode = 3
+
+// revertReasons contract/ERC721.sol 的 require 原因
+var revertReasons = map[string]*Error{
+	"over max supply.":  New(http.StatusUnprocessableEntity, CodeOverMaxSupply, "mint amount exceeds remaining supply"),
+	"incorrect payment": New(http.StatusUnprocessableEntity, CodeIncorrectPayment, "value must equal mint price times amount"),
+	"no funds":          New(http.StatusUnprocessableEntity, CodeNoFunds, "contract has no funds to withdraw"),
+	"withdraw failed":   New(http.StatusUnprocessableEntity, CodeWithdrawFailed, "withdraw transfer failed"),
+}
+
+// reverted 依節點回傳的 "execution reverted: <reason>" 找出對應的錯誤；其他原因（例如 onlyOwner）一律為 execution_reverted
+func reverted(msg string) *Error {
+	reason := strings.TrimSpace(strings.TrimPrefix(msg, "execution reverted:"))
+	if e, ok := revertReasons[reason]; ok {
+		return e
+	}
+	return New(http.StatusUnprocessableEntity, CodeExecutionReverted, "transaction reverted by contract")
+}
+
+// From 將任意 error 轉成 *Error；無法辨識的一律當成 internal error
+func From(err error) *Error {
+	if e, ok := typed(err); ok {
+		return e
+	}
+	return Internal(err)
+}
+
+// invalidArgument 服務層（ethcli、nft）回報呼叫端參數錯誤的錯誤實作這個方法，不需要依賴 httpapi
+type invalidArgument interface {
+	error
+	InvalidArgument() bool
+}
+
+// notFound 服務層回報查無資源的錯誤實作這個方法
+type notFound interface {
+	error
+	NotFound() bool
+}
+
+// typed 已經是 *Error，或是可以直接對應狀態碼的標準錯誤與服務層錯誤
+func typed(err error) (*Error, bool) {
+	var e *Error
+	var tooLarge *http.MaxBytesError
+	var invalid invalidArgument
+	var missing notFound
+	switch {
+	case err == nil:
+		return nil, false
+	case errors.As(err, &e):
+		return e, true
+	case errors.As(err, &invalid) && invalid.InvalidArgument():
+		return Invalid("%s", invalid.Error()).Wrap(err), true
+	case errors.As(err, &missing) && missing.NotFound():
+		return NotFound("%s", missing.Error()).Wrap(err), true
+	case errors.Is(err, sql.ErrNoRows):
+		return NotFound("resource not found").Wrap(err), true
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(http.StatusGatewayTimeout, CodeTimeout, "request timed out").Wrap(err), true
+	case errors.Is(err, context.Canceled):
+		return New(http.StatusRequestTimeout, CodeCanceled, "request canceled").Wrap(err), true
+	case errors.As(err, &tooLarge):
+		return New(http.StatusRequestEntityTooLarge, CodeTooLarge, "request body must not exceed %d bytes", tooLarge.Limit).Wrap(err), true
+	}
+	return nil, false
+}
@@ -0,0 +1,93 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/wkchen007/nftweb-back/internal/logging"
+)
+
+// JSONResponse 訊息與錯誤回應共用的格式
+type JSONResponse struct {
+	Error     bool        `json:"error"`
+	Code      Code        `json:"code,omitempty"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	RequestID string      `json:"requestId,omitempty"` // 錯誤回應帶上，方便對照 log
+}
+
+// maxBodyBytes JSON body 的上限
+const maxBodyBytes = 1024 * 1024 // one megabyte
+
+func WriteJSON(w http.ResponseWriter, status int, data interface{}, headers ...http.Header) error {
+	out, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if len(headers) > 0 {
+		for key, value := range headers[0] {
+			w.Header()[key] = value
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(out)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReadJSON 解析單一 JSON 值，不允許未知欄位；錯誤為 *Error
+func ReadJSON(w http.ResponseWriter, r *http.Request, data interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(data)
+	if err == io.EOF {
+		return New(http.StatusBadRequest, CodeInvalidJSON, "no request body")
+	}
+	if err != nil {
+		if e, ok := typed(err); ok {
+			return e
+		}
+		return New(http.StatusBadRequest, CodeInvalidJSON, "%s", err.Error())
+	}
+
+	err = dec.Decode(&struct{}{})
+	if err != io.EOF {
+		return New(http.StatusBadRequest, CodeInvalidJSON, "body must only contain a single JSON value")
+	}
+
+	return nil
+}
+
+// WriteError 輸出錯誤回應；5xx 的完整原因只寫進 log，不回給用戶端
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	e := From(err)
+	if e.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "[http] request failed", "status", e.Status, "code", e.Code, "err", err)
+	} else if e.Status == http.StatusUnprocessableEntity && e.Err != nil {
+		// 合約 revert 的原始訊息不回給用戶端，記在 log 方便對照
+		slog.WarnContext(r.Context(), "[http] contract reverted", "code", e.Code, "err", err)
+	} else if e.Err != nil {
+		slog.DebugContext(r.Context(), "[http] request rejected", "status", e.Status, "code", e.Code, "err", err)
+	}
+
+	payload := JSONResponse{
+		Error:     true,
+		Code:      e.Code,
+		Message:   e.Message,
+		Details:   e.Details,
+		RequestID: w.Header().Get(logging.RequestIDHeader),
+	}
+	_ = WriteJSON(w, e.Status, payload)
+}
@@ -0,0 +1,207 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Header 用戶端帶的 idempotency key
+const Header = "Idempotency-Key"
+
+// ReplayedHeader 回應由先前的結果重播時設為 true
+const ReplayedHeader = "Idempotent-Replayed"
+
+// MaxKeyLength key 的長度上限
+const MaxKeyLength = 255
+
+var (
+	ErrMismatch   = errors.New("idempotency key was already used with a different request")
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+	ErrNotHeld    = errors.New("idempotency record is no longer held by this request")
+)
+
+// Config key 的保存時間
+type Config struct {
+	TTL        time.Duration `yaml:"ttl"`        // 完成的回應保存多久
+	PendingTTL time.Duration `yaml:"pendingTTL"` // 處理中的記錄最多保留多久（需大於送交易的逾時，避免還在送就被當成沒有）
+	Wait       time.Duration `yaml:"wait"`       // 同一個 key 正在處理時，重複的請求最多等待多久
+}
+
+func DefaultConfig() Config {
+	return Config{
+		TTL:        24 * time.Hour,
+		PendingTTL: 2 * time.Minute,
+		Wait:       30 * time.Second,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.TTL <= 0 || c.PendingTTL <= 0 || c.Wait < 0 {
+		return fmt.Errorf("ttl and pendingTTL must be positive, wait must not be negative")
+	}
+	return nil
+}
+
+// Record 一個 key 的狀態；Done 之前只有 Fingerprint 與取得這個 key 的請求的 Token
+type Record struct {
+	Fingerprint string `json:"fingerprint"`
+	Token       string `json:"token,omitempty"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Fingerprint 以 method、path、query 與 body 識別同一個請求；query 需先正規化（例如 url.Values.Encode 依 key 排序）
+func Fingerprint(method, path, query string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "?" + query + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// pollInterval 等待處理中的請求時，重新讀取記錄的間隔
+const pollInterval = 100 * time.Millisecond
+
+// Store 以 Redis 保存 key 的狀態，多台 API 共用
+type Store struct {
+	rdb *redis.Client
+	cfg Config
+}
+
+func New(rdb *redis.Client, cfg Config) *Store {
+	return &Store{rdb: rdb, cfg: cfg}
+}
+
+func redisKey(key string) string {
+	return "idempotency:" + key
+}
+
+// Begin 第一次使用 key 時記錄為處理中，回傳之後 Complete / Abort 要帶的 token；key 已完成時回傳保存的回應。
+// key 正在處理時等待最多 Wait，仍未完成回傳 ErrInProgress；fingerprint 不同回傳 ErrMismatch
+func (s *Store) Begin(ctx context.Context, key, fingerprint string) (*Record, string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(b)
+	pending, err := json.Marshal(Record{Fingerprint: fingerprint, Token: token})
+	if err != nil {
+		return nil, "", err
+	}
+
+	deadline := time.Now().Add(s.cfg.Wait)
+	for {
+		ok, err := s.rdb.SetNX(ctx, redisKey(key), pending, s.cfg.PendingTTL).Result()
+		if err != nil {
+			return nil, "", err
+		}
+		if ok {
+			return nil, token, nil
+		}
+
+		b, err := s.rdb.Get(ctx, redisKey(key)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			// 剛好過期或被放棄，重新搶
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		var rec Record
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return nil, "", err
+		}
+		if rec.Fingerprint != fingerprint {
+			return nil, "", ErrMismatch
+		}
+		if rec.Done {
+			return &rec, "", nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, "", ErrInProgress
+		}
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// heldScript 記錄仍是這個請求的處理中記錄（token 與 fingerprint 相同且尚未完成）時，
+// ARGV[3] 為空則刪除，否則寫入 ARGV[3] 並設定 ARGV[4] 毫秒的 TTL；回傳是否成功
+var heldScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if not v then
+	return 0
+end
+local rec = cjson.decode(v)
+if rec.done or rec.token ~= ARGV[1] or rec.fingerprint ~= ARGV[2] then
+	return 0
+end
+if ARGV[3] == '' then
+	redis.call('DEL', KEYS[1])
+else
+	redis.call('SET', KEYS[1], ARGV[3], 'PX', ARGV[4])
+end
+return 1
+`)
+
+func (s *Store) replaceHeld(ctx context.Context, key, token, fingerprint string, value []byte, ttl time.Duration) error {
+	ok, err := heldScript.Run(ctx, s.rdb, []string{redisKey(key)}, token, fingerprint, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Complete 保存最後的回應；記錄已過期並被其他請求取得時不覆蓋，回傳 ErrNotHeld
+func (s *Store) Complete(ctx context.Context, key, token string, rec Record) error {
+	rec.Done = true
+	rec.Token = ""
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.replaceHeld(ctx, key, token, rec.Fingerprint, b, s.cfg.TTL)
+}
+
+// Abort 交易送出前就失敗時刪除記錄，讓用戶端可以用同一個 key 重試；
+// 記錄已不屬於這個請求時不刪除，回傳 ErrNotHeld
+func (s *Store) Abort(ctx context.Context, key, token, fingerprint string) error {
+	return s.replaceHeld(ctx, key, token, fingerprint, nil, 0)
+}
+
+type sentKey struct{}
+
+// WithSendTracker 在 ctx 中放入「交易是否已送出」的記錄，供 MarkSent / Sent 使用
+func WithSendTracker(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sentKey{}, new(atomic.Bool))
+}
+
+// MarkSent 在交易送往節點前呼叫：之後的失敗（例如 502 / 504）交易可能已上鏈，不可讓同一個 key 重新執行
+func MarkSent(ctx context.Context) {
+	if sent, ok := ctx.Value(sentKey{}).(*atomic.Bool); ok {
+		sent.Store(true)
+	}
+}
+
+// Sent 這個請求是否已送出過交易
+func Sent(ctx context.Context) bool {
+	sent, ok := ctx.Value(sentKey{}).(*atomic.Bool)
+	return ok && sent.Load()
+}
@@ -0,0 +1,195 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return New(rdb, Config{TTL: time.Hour, PendingTTL: time.Minute}), mr
+}
+
+func TestBegin(t *testing.T) {
+	ctx := context.Background()
+	fp := Fingerprint("POST", "/wallet/transfer", "", []byte(`{"to":"0x1"}`))
+	other := Fingerprint("POST", "/wallet/transfer", "", []byte(`{"to":"0x2"}`))
+	done := Record{Fingerprint: fp, Status: 200, ContentType: "application/json", Body: []byte(`{"txHash":"0xabc"}`)}
+
+	tests := []struct {
+		name        string
+		setup       func(t *testing.T, s *Store)
+		fingerprint string
+		want        *Record
+		wantErr     error
+	}{
+		{"first use", func(t *testing.T, s *Store) {}, fp, nil, nil},
+		{"replay completed", func(t *testing.T, s *Store) {
+			_, token := begin(t, s, fp)
+			if err := s.Complete(ctx, "k", token, done); err != nil {
+				t.Fatal(err)
+			}
+		}, fp, &done, nil},
+		{"mismatch completed", func(t *testing.T, s *Store) {
+			_, token := begin(t, s, fp)
+			if err := s.Complete(ctx, "k", token, done); err != nil {
+				t.Fatal(err)
+			}
+		}, other, nil, ErrMismatch},
+		{"mismatch pending", func(t *testing.T, s *Store) { begin(t, s, fp) }, other, nil, ErrMismatch},
+		{"pending", func(t *testing.T, s *Store) { begin(t, s, fp) }, fp, nil, ErrInProgress},
+		{"retry after abort", func(t *testing.T, s *Store) {
+			_, token := begin(t, s, fp)
+			if err := s.Abort(ctx, "k", token, fp); err != nil {
+				t.Fatal(err)
+			}
+		}, fp, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestStore(t)
+			tt.setup(t, s)
+
+			rec, token, err := s.Begin(ctx, "k", tt.fingerprint)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Begin err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Begin: %v", err)
+			}
+			if tt.want == nil {
+				if rec != nil || token == "" {
+					t.Fatalf("Begin = %+v, %q; want a new token", rec, token)
+				}
+				return
+			}
+			if rec == nil || token != "" {
+				t.Fatalf("Begin = %+v, %q; want the stored response", rec, token)
+			}
+			if !rec.Done || rec.Status != tt.want.Status || rec.ContentType != tt.want.ContentType || string(rec.Body) != string(tt.want.Body) {
+				t.Fatalf("replayed %+v, want %+v", rec, tt.want)
+			}
+		})
+	}
+}
+
+func TestBeginWaitsForPending(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestStore(t)
+	s.cfg.Wait = 5 * time.Second
+	_, token := begin(t, s, "fp")
+
+	errc := make(chan error, 1)
+	go func() {
+		time.Sleep(2 * pollInterval)
+		errc <- s.Complete(ctx, "k", token, Record{Fingerprint: "fp", Status: 201})
+	}()
+	rec, _, err := s.Begin(ctx, "k", "fp")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if rec == nil || rec.Status != 201 {
+		t.Fatalf("Begin = %+v, want the completed response", rec)
+	}
+}
+
+func TestCompleteAbortNotHeld(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		run  func(s *Store, token string) error
+	}{
+		{"complete wrong token", func(s *Store, token string) error {
+			return s.Complete(ctx, "k", "other", Record{Fingerprint: "fp"})
+		}},
+		{"complete wrong fingerprint", func(s *Store, token string) error {
+			return s.Complete(ctx, "k", token, Record{Fingerprint: "other"})
+		}},
+		{"abort wrong token", func(s *Store, token string) error {
+			return s.Abort(ctx, "k", "other", "fp")
+		}},
+		{"complete twice", func(s *Store, token string) error {
+			if err := s.Complete(ctx, "k", token, Record{Fingerprint: "fp", Status: 200}); err != nil {
+				return err
+			}
+			return s.Complete(ctx, "k", token, Record{Fingerprint: "fp", Status: 500})
+		}},
+		{"abort after complete", func(s *Store, token string) error {
+			if err := s.Complete(ctx, "k", token, Record{Fingerprint: "fp", Status: 200}); err != nil {
+				return err
+			}
+			return s.Abort(ctx, "k", token, "fp")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestStore(t)
+			_, token := begin(t, s, "fp")
+			if err := tt.run(s, token); !errors.Is(err, ErrNotHeld) {
+				t.Fatalf("err = %v, want ErrNotHeld", err)
+			}
+		})
+	}
+}
+
+// 處理中的記錄過期並被另一個請求取得後，原本的請求不可覆蓋或刪除
+func TestExpiredPendingNotOverwritten(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newTestStore(t)
+	_, stale := begin(t, s, "fp")
+
+	mr.FastForward(s.cfg.PendingTTL + time.Second)
+	_, fresh := begin(t, s, "fp")
+
+	if err := s.Complete(ctx, "k", stale, Record{Fingerprint: "fp", Status: 500}); !errors.Is(err, ErrNotHeld) {
+		t.Fatalf("stale Complete err = %v, want ErrNotHeld", err)
+	}
+	if err := s.Abort(ctx, "k", stale, "fp"); !errors.Is(err, ErrNotHeld) {
+		t.Fatalf("stale Abort err = %v, want ErrNotHeld", err)
+	}
+	if err := s.Complete(ctx, "k", fresh, Record{Fingerprint: "fp", Status: 200}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if ttl := mr.TTL(redisKey("k")); ttl != s.cfg.TTL {
+		t.Fatalf("completed ttl = %s, want %s", ttl, s.cfg.TTL)
+	}
+}
+
+func TestSendTracker(t *testing.T) {
+	ctx := context.Background()
+	MarkSent(ctx) // 沒有 tracker 時不做事
+	if Sent(ctx) {
+		t.Fatal("Sent without tracker")
+	}
+	ctx = WithSendTracker(ctx)
+	if Sent(ctx) {
+		t.Fatal("Sent before MarkSent")
+	}
+	MarkSent(ctx)
+	if !Sent(ctx) {
+		t.Fatal("not Sent after MarkSent")
+	}
+}
+
+func begin(t *testing.T, s *Store, fingerprint string) (*Record, string) {
+	t.Helper()
+	rec, token, err := s.Begin(context.Background(), "k", fingerprint)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	return rec, token
+}
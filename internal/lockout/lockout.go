@@ -0,0 +1,165 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Rule 前 Free 次失敗不延遲，之後每次失敗延遲加倍，達到 Max 次時鎖定
+type Rule struct {
+	Free int `yaml:"free"`
+	Max  int `yaml:"max"`
+}
+
+// Config 登入失敗的計數與鎖定；email 與來源 IP 分開計數
+type Config struct {
+	Enabled      bool          `yaml:"enabled"`
+	Email        Rule          `yaml:"email"`
+	IP           Rule          `yaml:"ip"`
+	Window       time.Duration `yaml:"window"`       // 最後一次失敗後保留計數的時間
+	BaseDelay    time.Duration `yaml:"baseDelay"`    // 第一次延遲
+	MaxDelay     time.Duration `yaml:"maxDelay"`     // 延遲上限
+	LockDuration time.Duration `yaml:"lockDuration"` // 鎖定時間
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Enabled:      true,
+		Email:        Rule{Free: 3, Max: 10},
+		IP:           Rule{Free: 10, Max: 50},
+		Window:       15 * time.Minute,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockDuration: 15 * time.Minute,
+	}
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	for name, r := range map[string]Rule{"email": c.Email, "ip": c.IP} {
+		if r.Free < 0 || r.Max <= r.Free {
+			return fmt.Errorf("%s: max must be greater than free", name)
+		}
+	}
+	if c.Window <= 0 || c.BaseDelay <= 0 || c.MaxDelay < c.BaseDelay || c.LockDuration <= 0 {
+		return fmt.Errorf("window, baseDelay, maxDelay and lockDuration must be positive (maxDelay >= baseDelay)")
+	}
+	return nil
+}
+
+// Result 一次失敗之後的狀態
+type Result struct {
+	Wait   time.Duration // 下次可嘗試前需等待的時間
+	Locked bool          // 這次失敗讓 email 進入鎖定
+}
+
+// checkScript 回傳所有 key 中最長的剩餘等待毫秒數
+var checkScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local wait = 0
+for _, key in ipairs(KEYS) do
+	local untilMs = tonumber(redis.call('HGET', key, 'until') or '0')
+	if untilMs - now > wait then
+		wait = untilMs - now
+	end
+end
+return wait
+`)
+
+// failScript 記一次失敗並設定下次可嘗試的時間；回傳 {fails, wait_ms}
+var failScript = redis.NewScript(`
+local key = KEYS[1]
+local free = tonumber(ARGV[1])
+local max = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local base = tonumber(ARGV[4])
+local maxDelay = tonumber(ARGV[5])
+local lock = tonumber(ARGV[6])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local fails = redis.call('HINCRBY', key, 'fails', 1)
+local wait = 0
+if fails >= max then
+	wait = lock
+elseif fails > free then
+	wait = math.min(base * 2 ^ (fails - free - 1), maxDelay)
+end
+redis.call('HSET', key, 'until', now + wait)
+redis.call('PEXPIRE', key, math.max(window, wait))
+return {fails, wait}
+`)
+
+// Tracker 以 Redis 記錄登入失敗，多台 API 共用
+type Tracker struct {
+	rdb *redis.Client
+	cfg Config
+}
+
+func New(rdb *redis.Client, cfg Config) *Tracker {
+	return &Tracker{rdb: rdb, cfg: cfg}
+}
+
+func emailKey(email string) string {
+	return "lockout:email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "lockout:ip:" + ip
+}
+
+// Check email 或 ip 仍在延遲 / 鎖定中時回傳剩餘時間
+func (t *Tracker) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	if !t.cfg.Enabled {
+		return 0, nil
+	}
+	ms, err := checkScript.Run(ctx, t.rdb, []string{emailKey(email), ipKey(ip)}).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Fail 記一次失敗（帳號不存在也要記，避免以 IP 大量猜測）
+func (t *Tracker) Fail(ctx context.Context, email, ip string) (Result, error) {
+	if !t.cfg.Enabled {
+		return Result{}, nil
+	}
+	emailFails, emailWait, err := t.fail(ctx, emailKey(email), t.cfg.Email)
+	if err != nil {
+		return Result{}, err
+	}
+	_, ipWait, err := t.fail(ctx, ipKey(ip), t.cfg.IP)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Wait:   max(emailWait, ipWait),
+		Locked: emailFails >= int64(t.cfg.Email.Max),
+	}, nil
+}
+
+func (t *Tracker) fail(ctx context.Context, key string, r Rule) (int64, time.Duration, error) {
+	res, err := failScript.Run(ctx, t.rdb, []string{key},
+		r.Free, r.Max, t.cfg.Window.Milliseconds(), t.cfg.BaseDelay.Milliseconds(),
+		t.cfg.MaxDelay.Milliseconds(), t.cfg.LockDuration.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	return res[0], time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// Reset 登入成功後清除 email 的失敗計數（IP 的計數保留，避免用自己的帳號重置）
+func (t *Tracker) Reset(ctx context.Context, email string) error {
+	if !t.cfg.Enabled {
+		return nil
+	}
+	return t.rdb.Del(ctx, emailKey(email)).Err()
+}
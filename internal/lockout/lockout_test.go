@@ -0,0 +1,179 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestTracker(t *testing.T, cfg Config) (*Tracker, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Unix(1700000000, 0))
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return New(rdb, cfg), mr
+}
+
+func testConfig() Config {
+	return Config{
+		Enabled:      true,
+		Email:        Rule{Free: 2, Max: 6},
+		IP:           Rule{Free: 3, Max: 8},
+		Window:       15 * time.Minute,
+		BaseDelay:    time.Second,
+		MaxDelay:     3 * time.Second,
+		LockDuration: 30 * time.Minute,
+	}
+}
+
+func TestFailDelayProgression(t *testing.T) {
+	tests := []struct {
+		name   string
+		fail   func(ctx context.Context, tr *Tracker, i int) (Result, error)
+		want   []time.Duration
+		locked int // 第幾次失敗開始鎖定 email（0 表示不鎖定）
+	}{
+		{
+			// email：前 2 次不延遲，之後 1s、2s，第 5 次受 maxDelay 3s 限制，第 6 次鎖定 30m
+			name: "email",
+			fail: func(ctx context.Context, tr *Tracker, i int) (Result, error) {
+				return tr.Fail(ctx, "User@Example.com", "198.51.100.1")
+			},
+			want:   []time.Duration{0, 0, time.Second, 2 * time.Second, 3 * time.Second, 30 * time.Minute},
+			locked: 6,
+		},
+		{
+			// 同一個 IP 換 email：依 ip 的規則，前 3 次不延遲，第 8 次鎖定但不算 email 鎖定
+			name: "ip",
+			fail: func(ctx context.Context, tr *Tracker, i int) (Result, error) {
+				return tr.Fail(ctx, string(rune('a'+i))+"@example.com", "198.51.100.1")
+			},
+			want: []time.Duration{0, 0, 0, time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second, 30 * time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tr, _ := newTestTracker(t, testConfig())
+			for i, want := range tt.want {
+				res, err := tt.fail(ctx, tr, i)
+				if err != nil {
+					t.Fatalf("Fail #%d: %v", i+1, err)
+				}
+				if res.Wait != want {
+					t.Fatalf("Fail #%d wait = %s, want %s", i+1, res.Wait, want)
+				}
+				if wantLocked := tt.locked > 0 && i+1 >= tt.locked; res.Locked != wantLocked {
+					t.Fatalf("Fail #%d locked = %v, want %v", i+1, res.Locked, wantLocked)
+				}
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	tr, mr := newTestTracker(t, testConfig())
+
+	for i := 0; i < 4; i++ {
+		if _, err := tr.Fail(ctx, "user@example.com", "198.51.100.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name    string
+		email   string
+		ip      string
+		advance time.Duration
+		want    time.Duration
+	}{
+		{"email delayed", " USER@example.com ", "203.0.113.9", 0, 2 * time.Second},
+		{"ip delayed", "other@example.com", "198.51.100.1", 0, time.Second},
+		{"unrelated", "other@example.com", "203.0.113.9", 0, 0},
+		{"partly elapsed", "user@example.com", "203.0.113.9", 1500 * time.Millisecond, 500 * time.Millisecond},
+		{"elapsed", "user@example.com", "198.51.100.1", time.Second, 0},
+	}
+	now := time.Unix(1700000000, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			mr.SetTime(now)
+			wait, err := tr.Check(ctx, tt.email, tt.ip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wait != tt.want {
+				t.Fatalf("Check wait = %s, want %s", wait, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockExpiry(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	tr, mr := newTestTracker(t, cfg)
+
+	for i := 0; i < cfg.Email.Max; i++ {
+		if _, err := tr.Fail(ctx, "user@example.com", "198.51.100.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 鎖定比 window 長時，計數保留到鎖定結束
+	if ttl := mr.TTL(emailKey("user@example.com")); ttl != cfg.LockDuration {
+		t.Fatalf("locked ttl = %s, want %s", ttl, cfg.LockDuration)
+	}
+
+	mr.FastForward(cfg.LockDuration)
+	res, err := tr.Fail(ctx, "user@example.com", "203.0.113.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Wait != 0 || res.Locked {
+		t.Fatalf("after lock expiry = %+v, want a fresh count", res)
+	}
+}
+
+func TestReset(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig()
+	tr, _ := newTestTracker(t, cfg)
+
+	for i := 0; i < cfg.Email.Free+1; i++ {
+		if _, err := tr.Fail(ctx, "user@example.com", "198.51.100.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tr.Reset(ctx, "User@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	// email 的計數歸零，IP 的計數保留
+	res, err := tr.Fail(ctx, "user@example.com", "198.51.100.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Wait != time.Second {
+		t.Fatalf("wait after reset = %s, want the ip delay 1s", res.Wait)
+	}
+	if wait, err := tr.Check(ctx, "user@example.com", "203.0.113.9"); err != nil || wait != 0 {
+		t.Fatalf("email wait after reset = %s (err %v), want 0", wait, err)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	ctx := context.Background()
+	tr := New(nil, Config{})
+	if wait, err := tr.Check(ctx, "user@example.com", "198.51.100.1"); err != nil || wait != 0 {
+		t.Fatalf("Check = %s, %v", wait, err)
+	}
+	if res, err := tr.Fail(ctx, "user@example.com", "198.51.100.1"); err != nil || res != (Result{}) {
+		t.Fatalf("Fail = %+v, %v", res, err)
+	}
+	if err := tr.Reset(ctx, "user@example.com"); err != nil {
+		t.Fatal(err)
+	}
+}
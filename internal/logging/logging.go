@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const redacted = "******"
+
+// sensitiveKeys 欄位名稱（不分大小寫、忽略 _ 與 -）包含這些字就整個遮蔽；
+// token 只看結尾（refresh_token、accessToken），避免遮到 tokenId、tokenExpiry
+var sensitiveKeys = []string{"password", "privatekey", "secret", "cookie", "authorization", "apikey", "passphrase", "mnemonic"}
+
+// IsSensitive 欄位名稱是否屬於敏感資料
+func IsSensitive(key string) bool {
+	k := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	if strings.HasSuffix(k, "token") {
+		return true
+	}
+	for _, s := range sensitiveKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// key=value、key: value、"key":"value" 形式（含 %+v 輸出的 PrivateKey:xxx 與 query string）
+	kvPattern = regexp.MustCompile(`(?i)("?(?:[a-z_]*(?:password|private_?key|secret|cookie|authorization|api_?key|passphrase|mnemonic)[a-z_]*|[a-z_]*token)"?\s*[:=]\s*"?)(?:bearer\s+)?[^\s,"&}\]]+`)
+	// Bearer token 與 JWT
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[a-z0-9\-_.=]+`)
+	jwtPattern    = regexp.MustCompile(`eyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+`)
+	// 沒有 0x 前綴的 32 bytes hex 視為私鑰（tx hash / 地址都帶 0x，不受影響）
+	hexKeyPattern = regexp.MustCompile(`(^|[^0-9a-fA-Fx])[0-9a-fA-F]{64}\b`)
+	// URL 裡的帳密
+	userinfoPattern = regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+@`)
+)
+
+// Scrub 遮蔽字串中看起來像密鑰的片段
+func Scrub(s string) string {
+	s = kvPattern.ReplaceAllString(s, "${1}"+redacted)
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = hexKeyPattern.ReplaceAllString(s, "${1}"+redacted)
+	s = userinfoPattern.ReplaceAllString(s, "${1}"+redacted+"@")
+	return s
+}
+
+// replaceAttr 敏感欄位整個遮蔽，其他字串（含訊息本身）掃過一次
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.MessageKey && IsSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Scrub(err.Error()))
+		}
+	}
+	return a
+}
+
+// New 建立 JSON 格式、會自動遮蔽敏感資料的 logger；以 slog.*Context 記錄時會帶上 ctx 的 request id 與 trace id
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceAttr,
+	})})
+}
+
+// Setup 設定為預設 logger；標準庫 log.Printf 也會經過同一個 handler 遮蔽
+func Setup(env string) *slog.Logger {
+	level := slog.LevelInfo
+	if env == "development" {
+		level = slog.LevelDebug
+	}
+	logger := New(os.Stderr, level)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// Redacted 以 JSON 欄位名稱遮蔽結構內的敏感欄位後記錄，例如 slog.Any("req", logging.Redacted(req))
+func Redacted(v interface{}) slog.LogValuer {
+	return redactedValue{v}
+}
+
+type redactedValue struct{ v interface{} }
+
+func (r redactedValue) LogValue() slog.Value {
+	b, err := json.Marshal(r.v)
+	if err != nil {
+		return slog.StringValue(redacted)
+	}
+	var data interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return slog.StringValue(redacted)
+	}
+	return slog.AnyValue(redactJSON(data))
+}
+
+func redactJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if IsSensitive(k) {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redactJSON(val)
+		}
+		return t
+	case []interface{}:
+		for i := range t {
+			t[i] = redactJSON(t[i])
+		}
+		return t
+	case string:
+		return Scrub(t)
+	default:
+		return t
+	}
+}
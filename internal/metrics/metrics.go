@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 所有指標註冊在 Prometheus 預設 registry（含 Go runtime 與 process 指標），由 /metrics 輸出
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by chi route pattern and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	RPCCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ethcli_rpc_calls_total",
+		Help: "JSON-RPC calls sent to the Ethereum node by method.",
+	}, []string{"chain", "method"})
+
+	RPCErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ethcli_rpc_errors_total",
+		Help: "JSON-RPC calls that failed upstream (transport, HTTP status or JSON-RPC error) by method.",
+	}, []string{"chain", "method"})
+
+	RPCDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ethcli_rpc_duration_seconds",
+		Help:    "JSON-RPC round trip latency by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"chain", "method"})
+
+	TxSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "eth_transactions_sent_total",
+		Help: "Transactions signed and sent by contract method; result is ok or error.",
+	}, []string{"chain", "contract", "method", "result"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "PostgresDBRepo method latency.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 3},
+	}, []string{"query"})
+
+	AMQPPublish = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "amqp_publish_total",
+		Help: "Messages published to RabbitMQ; result is ok or error.",
+	}, []string{"exchange", "result"})
+
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_rate_limited_total",
+		Help: "Requests rejected with 429 by route group.",
+	}, []string{"group"})
+
+	SignerBalance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "eth_signer_balance_ether",
+		Help: "Balance of the active signer on each chain.",
+	}, []string{"chain", "address"})
+
+	ContractBalance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "nft_contract_balance_ether",
+		Help: "Balance held by each NFT collection contract.",
+	}, []string{"collection", "contract"})
+)
+
+// Handler /metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Result 將 err 轉成 result label
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// Since 自 start 起經過的秒數
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// 空投批次狀態
+const (
+	AirdropPending = "pending"
+	AirdropRunning = "running"
+	AirdropDone    = "done"
+	AirdropPartial = "partial" // 有列失敗，可續傳
+)
+
+// 空投每列狀態
+const (
+	AirdropRowPending   = "pending"
+	AirdropRowInvalid   = "invalid"
+	AirdropRowSent      = "sent"      // 已送出，等待收據
+	AirdropRowConfirmed = "confirmed" // 收據成功
+	AirdropRowFailed    = "failed"
+)
+
+type Airdrop struct {
+	ID          int          `json:"id"`
+	Contract    string       `json:"contract"`
+	Status      string       `json:"status"`
+	TotalAmount int          `json:"totalAmount"`
+	ValueWei    string       `json:"valueWei"`
+	ValueETH    string       `json:"valueETH,omitempty"`
+	Rows        []AirdropRow `json:"rows,omitempty"`
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+type AirdropRow struct {
+	AirdropID int    `json:"-"`
+	RowNo     int    `json:"row"` // CSV 行號（從 1 開始）
+	Address   string `json:"address"`
+	Amount    int    `json:"amount"`
+	Status    string `json:"status"`
+	TxHash    string `json:"txHash,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// 盲盒分配狀態：先公開 commitment，開盒後再公開 seed 與 mapping
+const (
+	AssignmentCommitted = "committed"
+	AssignmentRevealed  = "revealed"
+)
+
+// Assignment tokenId → 商品 id 的分配結果；Mapping[tokenId] 即對應的 nft.id
+type Assignment struct {
+	ID         int        `json:"id"`
+	Contract   string     `json:"contract"`
+	Commitment string     `json:"commitment"`
+	Seed       string     `json:"seed,omitempty"`
+	Pool       []PoolItem `json:"pool"`
+	Mapping    []int      `json:"mapping,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RevealedAt *time.Time `json:"revealedAt,omitempty"`
+}
+
+// PoolItem 分配池中的一個商品，commit 時的稀有度、權重與份數；與 seed 一起即可重算 mapping
+type PoolItem struct {
+	ID     int    `json:"id"`
+	Rarity string `json:"rarity"`
+	Weight int    `json:"weight"`
+	Supply int    `json:"supply"`
+}
+
+// Public 開盒前隱藏 seed 與 mapping，只留 commitment 與分配池
+func (a Assignment) Public() Assignment {
+	if a.Status != AssignmentRevealed {
+		a.Seed = ""
+		a.Mapping = nil
+	}
+	return a
+}
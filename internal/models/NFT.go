@@ -1,11 +1,42 @@
 package models
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
 type NFT struct {
 	ID    int    `json:"id"`
 	Name  string `json:"name"`
 	Desc  string `json:"desc"`
 	Meta  string `json:"meta"`
 	Image string `json:"image"`
+	Demo  bool   `json:"demo"`
+
+	Collection string `json:"collection"` // 商品目錄命名空間（合集）
+
+	Rarity string `json:"rarity"`
+	Weight int    `json:"weight"` // 抽中權重，0 代表不參與分配
+	Supply int    `json:"supply"` // 可分配的份數
+}
+
+// 稀有度
+const (
+	RarityCommon = "common"
+	RarityRare   = "rare"
+	RarityEpic   = "epic"
+	RaritySecret = "secret"
+)
+
+func validRarity(r string) bool {
+	switch r {
+	case RarityCommon, RarityRare, RarityEpic, RaritySecret:
+		return true
+	}
+	return false
 }
 
 type TokenItem struct {
@@ -13,3 +44,76 @@ type TokenItem struct {
 	TokenURI string `json:"tokenURI,omitempty"`
 	ImageURI string `json:"imageURI,omitempty"`
 }
+
+var (
+	cidV0       = regexp.MustCompile(`^Qm[1-9A-HJ-NP-Za-km-z]{44}$`)
+	cidV1       = regexp.MustCompile(`^b[a-z2-7]{58,}$`)
+	cidPathPart = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// ValidCIDPath 檢查 IPFS CID（v0 Qm... 或 v1 base32 b...），可帶子路徑，例如 bafy.../0.json
+func ValidCIDPath(s string) error {
+	cid, path, _ := strings.Cut(s, "/")
+	if !cidV0.MatchString(cid) && !cidV1.MatchString(cid) {
+		return fmt.Errorf("invalid CID: %s", cid)
+	}
+	if path == "" {
+		return nil
+	}
+	for _, part := range strings.Split(path, "/") {
+		if part == "." || part == ".." || !cidPathPart.MatchString(part) {
+			return fmt.Errorf("invalid CID path: %s", path)
+		}
+	}
+	return nil
+}
+
+// Validate 檢查商品目錄欄位（長度對應 nft table 欄位）
+func (n *NFT) Validate() error {
+	if n.ID < 0 {
+		return fmt.Errorf("id must be >= 0")
+	}
+	if strings.TrimSpace(n.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(n.Name) > 50 {
+		return fmt.Errorf("name must be at most 50 characters")
+	}
+	if len(n.Meta) > 255 || len(n.Image) > 255 {
+		return fmt.Errorf("meta and image must be at most 255 characters")
+	}
+	if err := ValidCIDPath(n.Meta); err != nil {
+		return fmt.Errorf("meta: %w", err)
+	}
+	if err := ValidCIDPath(n.Image); err != nil {
+		return fmt.Errorf("image: %w", err)
+	}
+	if n.Rarity == "" {
+		n.Rarity = RarityCommon
+	}
+	if !validRarity(n.Rarity) {
+		return fmt.Errorf("invalid rarity: %s", n.Rarity)
+	}
+	if n.Weight < 0 || n.Supply < 0 {
+		return fmt.Errorf("weight and supply must be >= 0")
+	}
+	return nil
+}
+
+// 商品目錄異動動作
+const (
+	CatalogCreate = "create"
+	CatalogUpdate = "update"
+	CatalogDelete = "delete"
+)
+
+type CatalogAudit struct {
+	ID         int       `json:"id"`
+	Collection string    `json:"collection"`
+	NFTID      int       `json:"nftId"`
+	Action     string    `json:"action"`
+	UserID     int       `json:"userId"`
+	Before     string    `json:"before,omitempty"` // JSON
+	After      string    `json:"after,omitempty"`  // JSON
+	CreatedAt  time.Time `json:"createdAt"`
+}
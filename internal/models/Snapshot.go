@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// 快照來源：ownerOf 帶區塊高度查詢（需 archive 節點），或重播 Transfer log
+const (
+	SnapshotSourceOwnerOf  = "ownerOf"
+	SnapshotSourceTransfer = "transferLogs"
+)
+
+type HolderSnapshot struct {
+	ID          int              `json:"id"`
+	Contract    string           `json:"contract"`
+	BlockNumber uint64           `json:"blockNumber"`
+	Source      string           `json:"source"`
+	Holders     []SnapshotHolder `json:"holders,omitempty"`
+	CreatedAt   time.Time        `json:"createdAt"`
+}
+
+type SnapshotHolder struct {
+	Address  string   `json:"address"`
+	TokenIDs []string `json:"tokenIds"`
+	Count    int      `json:"count"`
+}
@@ -8,14 +8,41 @@ import (
 )
 
 type User struct {
-	ID            int       `json:"id"`
-	FirstName     string    `json:"first_name"`
-	LastName      string    `json:"last_name"`
-	Email         string    `json:"email"`
-	Password      string    `json:"password"`
-	WalletAddress string    `json:"wallet_address"`
-	CreatedAt     time.Time `json:"-"`
-	UpdatedAt     time.Time `json:"-"`
+	ID            int        `json:"id"`
+	FirstName     string     `json:"first_name"`
+	LastName      string     `json:"last_name"`
+	Email         string     `json:"email"`
+	Password      string     `json:"password"`
+	WalletAddress string     `json:"wallet_address"`
+	Role          string     `json:"role"`                   // user / admin
+	Disabled      bool       `json:"disabled"`               // 停用的帳號無法登入
+	LockedUntil   *time.Time `json:"locked_until,omitempty"` // 連續登入失敗後鎖定到此時間
+	CreatedAt     time.Time  `json:"-"`
+	UpdatedAt     time.Time  `json:"-"`
+}
+
+// 使用者角色
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsAdmin 可異動商品目錄與執行管理功能（停用的帳號一律不算）
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin && !u.Disabled
+}
+
+// Locked 目前是否仍在鎖定期間
+func (u *User) Locked(now time.Time) bool {
+	return u.LockedUntil != nil && u.LockedUntil.After(now)
+}
+
+// dummyPasswordHash 與種子帳號相同 cost 的 bcrypt hash，對應的明文已丟棄
+const dummyPasswordHash = "$2a$14$hiUKcWjJ2ZOFiptj2pE.9.b2omCaKMGDjRE3mHaqSsBrmarFIuBUO"
+
+// DummyPasswordCheck 帳號不存在時照樣跑一次 bcrypt，讓回應時間與存在的帳號相同
+func DummyPasswordCheck(plainText string) {
+	_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(plainText))
 }
 
 func (u *User) PasswordMatches(plainText string) (bool, error) {
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// webhook 事件類型
+const (
+	EventNFTMinted       = "nft.minted"
+	EventNFTTransferred  = "nft.transferred"
+	EventNFTApproval     = "nft.approval"
+	EventWalletConfirmed = "wallet.transfer.confirmed"
+)
+
+// webhook 投遞狀態
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+	DeliveryCanceled  = "canceled" // 訂閱已停用，不再投遞
+)
+
+type WebhookSubscription struct {
+	ID          int               `json:"id"`
+	URL         string            `json:"url"`
+	Secret      string            `json:"secret,omitempty"`
+	EventTypes  []string          `json:"eventTypes"`
+	Active      bool              `json:"active"`
+	StartBlocks map[uint64]uint64 `json:"startBlocks"` // 建立時各鏈（chain id）的區塊高度
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"-"`
+}
+
+// EventSource 鏈上事件所在的鏈與區塊
+type EventSource struct {
+	ChainID uint64
+	Block   uint64
+}
+
+// Accepts 判斷訂閱者是否要收此事件類型（* 代表全部）
+func (s *WebhookSubscription) Accepts(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == "*" || t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers 鏈上事件是否發生在訂閱建立之後；建立時沒有記錄該鏈的高度（舊訂閱）或非鏈上事件一律投遞
+func (s *WebhookSubscription) Covers(src *EventSource) bool {
+	if src == nil {
+		return true
+	}
+	start, ok := s.StartBlocks[src.ChainID]
+	return !ok || src.Block >= start
+}
+
+// JoinEventTypes / SplitEventTypes 將事件類型與 DB 欄位（逗號分隔）互轉
+func JoinEventTypes(types []string) string {
+	return strings.Join(types, ",")
+}
+
+func SplitEventTypes(s string) []string {
+	var out []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+type WebhookDelivery struct {
+	ID             int       `json:"id"`
+	SubscriptionID int       `json:"subscriptionId"`
+	EventID        string    `json:"eventId"`
+	EventType      string    `json:"eventType"`
+	Payload        string    `json:"payload"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	NextAttemptAt  time.Time `json:"nextAttemptAt"`
+	LastError      string    `json:"lastError,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type WebhookAttempt struct {
+	ID         int       `json:"id"`
+	DeliveryID int       `json:"deliveryId"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int       `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
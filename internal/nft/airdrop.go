@@ -0,0 +1,357 @@
+package nft
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/wkchen007/nftweb-back/internal/ethcli"
+	"github.com/wkchen007/nftweb-back/internal/models"
+	"github.com/wkchen007/nftweb-back/internal/repository"
+)
+
+const maxAirdropRows = 1000
+
+var ErrAirdropRunning = errors.New("airdrop is already running")
+
+// ParseAirdropCSV 解析 address,amount 兩欄的 CSV（第一列可為表頭），逐列驗證地址與數量
+func ParseAirdropCSV(r io.Reader) ([]models.AirdropRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, invalidInput("invalid csv: %s", err)
+	}
+	if len(records) > 0 && len(records[0]) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "address") {
+		records = records[1:]
+	}
+	if len(records) == 0 {
+		return nil, invalidInput("csv has no rows")
+	}
+	if len(records) > maxAirdropRows {
+		return nil, invalidInput("csv has %d rows, max %d", len(records), maxAirdropRows)
+	}
+
+	rows := make([]models.AirdropRow, 0, len(records))
+	for i, rec := range records {
+		row := models.AirdropRow{RowNo: i + 1, Status: models.AirdropRowPending}
+		if len(rec) != 2 {
+			row.Status = models.AirdropRowInvalid
+			row.Error = "expected 2 columns: address,amount"
+			rows = append(rows, row)
+			continue
+		}
+		row.Address = strings.TrimSpace(rec[0])
+		amount, err := strconv.Atoi(strings.TrimSpace(rec[1]))
+		switch {
+		case !gethcommon.IsHexAddress(row.Address):
+			row.Status = models.AirdropRowInvalid
+			row.Error = "invalid address"
+		case gethcommon.HexToAddress(row.Address) == (gethcommon.Address{}):
+			row.Status = models.AirdropRowInvalid
+			row.Error = "address cannot be zero address"
+		case err != nil || amount <= 0:
+			row.Status = models.AirdropRowInvalid
+			row.Error = "amount must be a positive integer"
+		default:
+			row.Address = gethcommon.HexToAddress(row.Address).Hex()
+			row.Amount = amount
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// PrepareAirdrop 驗證 CSV，確認總量不超過 maxSupply - counter 且 signer 餘額足夠，並計算所需金額
+func (s *Service) PrepareAirdrop(ctx context.Context, r io.Reader) (models.Airdrop, error) {
+	rows, err := ParseAirdropCSV(r)
+	if err != nil {
+		return models.Airdrop{}, err
+	}
+
+	total := 0
+	for _, row := range rows {
+		if row.Status == models.AirdropRowPending {
+			total += row.Amount
+		}
+	}
+	if total == 0 {
+		return models.Airdrop{Rows: rows}, invalidInput("csv has no valid rows")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
+	defer cancel()
+
+	if err := s.checkSupply(ctx, total); err != nil {
+		return models.Airdrop{Rows: rows}, err
+	}
+
+	value := new(big.Int).Mul(s.price, big.NewInt(int64(total)))
+	balance, err := s.client.Backend().BalanceAt(ctx, s.client.From(), nil)
+	if err != nil {
+		return models.Airdrop{}, fmt.Errorf("get signer balance: %w", err)
+	}
+	if balance.Cmp(value) < 0 {
+		return models.Airdrop{Rows: rows}, invalidInput("signer balance %s ETH is less than required %s ETH", ethcli.WeiToEtherString(balance), ethcli.WeiToEtherString(value))
+	}
+
+	return models.Airdrop{
+		Contract:    s.con.Address().Hex(),
+		Status:      models.AirdropPending,
+		TotalAmount: total,
+		ValueWei:    value.String(),
+		ValueETH:    ethcli.WeiToEtherString(value),
+		Rows:        rows,
+	}, nil
+}
+
+func (s *Service) checkSupply(ctx context.Context, amount int) error {
+	opts := &bind.CallOpts{Context: ctx}
+	counter, err := s.con.Counter(opts)
+	if err != nil {
+		return fmt.Errorf("get counter: %w", err)
+	}
+	maxSupply, err := s.con.MaxSupply(opts)
+	if err != nil {
+		return fmt.Errorf("get maxSupply: %w", err)
+	}
+	remaining := new(big.Int).Sub(maxSupply, counter)
+	if big.NewInt(int64(amount)).Cmp(remaining) > 0 {
+		return invalidInput("airdrop total %d exceeds remaining supply %s", amount, remaining)
+	}
+	return nil
+}
+
+// ClaimAirdrop 取得批次的執行權：先檢查本程序的記錄，再以 DB advisory lock 排除其他 replica；
+// 已在執行時回傳 ErrAirdropRunning。RunAirdrop 結束後呼叫 release 釋放
+func (s *Service) ClaimAirdrop(ctx context.Context, id int) (release func(), err error) {
+	s.tx.jobsMu.Lock()
+	defer s.tx.jobsMu.Unlock()
+	if s.tx.airdrops[id] {
+		return nil, ErrAirdropRunning
+	}
+	unlock, err := s.DB.TryLockAirdrop(ctx, id)
+	if errors.Is(err, repository.ErrLocked) {
+		return nil, ErrAirdropRunning
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock airdrop %d: %w", id, err)
+	}
+	s.tx.airdrops[id] = true
+
+	return func() {
+		s.tx.jobsMu.Lock()
+		delete(s.tx.airdrops, id)
+		s.tx.jobsMu.Unlock()
+		unlock()
+	}, nil
+}
+
+// RunAirdrop 送出尚未成功的列並等待收據，呼叫端需先以 ClaimAirdrop 取得執行權，可重複呼叫以續傳：
+// 已有 tx hash 的列先向節點確認，上鏈成功的標為 confirmed、仍在 mempool 的不重送，
+// revert 或節點已不認得的交易才重新送出（nonce 每筆重新查詢，不沿用舊的）
+func (s *Service) RunAirdrop(ctx context.Context, id int) error {
+	job, err := s.DB.GetAirdrop(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	todo, waiting, err := s.reconcileAirdrop(ctx, job.Rows)
+	if err != nil {
+		return err
+	}
+	if len(todo) == 0 && len(waiting) == 0 {
+		return s.DB.UpdateAirdropStatus(ctx, id, models.AirdropDone)
+	}
+
+	// 仍在 mempool 的交易還沒計入 counter，一併算進需要的供給
+	amount, pending := 0, 0
+	for _, row := range todo {
+		amount += row.Amount
+	}
+	for _, row := range waiting {
+		pending += row.Amount
+	}
+	if amount > 0 {
+		checkCtx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
+		err := s.checkSupply(checkCtx, amount+pending)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.DB.UpdateAirdropStatus(ctx, id, models.AirdropRunning); err != nil {
+		return err
+	}
+	return s.sendAirdropRows(ctx, id, todo, waiting)
+}
+
+// reconcileAirdrop 依節點上的交易狀態整理各列：todo 需要（重新）送出，waiting 已送出等待收據
+func (s *Service) reconcileAirdrop(ctx context.Context, rows []models.AirdropRow) (todo, waiting []models.AirdropRow, err error) {
+	for _, row := range rows {
+		switch row.Status {
+		case models.AirdropRowPending, models.AirdropRowFailed, models.AirdropRowSent:
+		default:
+			continue
+		}
+		if row.TxHash == "" {
+			todo = append(todo, row)
+			continue
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
+		state, err := s.sentTxState(checkCtx, gethcommon.HexToHash(row.TxHash))
+		cancel()
+		if err != nil {
+			return nil, nil, fmt.Errorf("check airdrop row %d tx %s: %w", row.RowNo, row.TxHash, err)
+		}
+		switch state {
+		case txSucceeded:
+			row.Status = models.AirdropRowConfirmed
+			row.Error = ""
+			if err := s.DB.UpdateAirdropRow(ctx, row); err != nil {
+				return nil, nil, fmt.Errorf("update airdrop row %d: %w", row.RowNo, err)
+			}
+		case txPending:
+			row.Status = models.AirdropRowSent
+			waiting = append(waiting, row)
+		case txReverted:
+			row.Error = "transaction reverted"
+			todo = append(todo, row)
+		default:
+			// 節點不認得這筆交易（未送達或已被丟棄），重新送出
+			todo = append(todo, row)
+		}
+	}
+	return todo, waiting, nil
+}
+
+func (s *Service) sendAirdropRows(ctx context.Context, id int, todo, waiting []models.AirdropRow) error {
+	// 交易已簽好就必須記下 tx hash，不因關閉服務而漏寫（否則 resume 會重複鑄造）
+	dbCtx := context.WithoutCancel(ctx)
+	failed := 0
+	for _, row := range todo {
+		if ctx.Err() != nil {
+			break
+		}
+
+		to := gethcommon.HexToAddress(row.Address)
+		amount := big.NewInt(int64(row.Amount))
+		value := new(big.Int).Mul(s.price, amount)
+
+		txCtx, cancel := context.WithTimeout(ctx, s.timeouts.Tx)
+		hash, err := s.sendTxWith(txCtx, "mint", value, func(tx *types.Transaction) error {
+			row.Status = models.AirdropRowSent
+			row.TxHash = tx.Hash().Hex()
+			row.Error = ""
+			return s.DB.UpdateAirdropRow(dbCtx, row)
+		}, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return s.con.Mint(opts, to, amount)
+		})
+		if err != nil && hash != nil {
+			// 送出結果不明：節點可能已收到，以 hash 確認
+			if state, checkErr := s.sentTxState(txCtx, *hash); checkErr == nil && state != txUnknown {
+				err = nil
+			}
+		}
+		cancel()
+
+		if err == nil {
+			waiting = append(waiting, row)
+			continue
+		}
+		// 簽名前失敗（例如 estimate gas revert）沒有 hash；送出失敗時保留 hash，resume 時先確認再重送
+		row.Status = models.AirdropRowFailed
+		row.Error = err.Error()
+		failed++
+		slog.ErrorContext(ctx, "[nft] airdrop row failed", "id", id, "row", row.RowNo, "tx", row.TxHash, "err", err)
+		if err := s.DB.UpdateAirdropRow(dbCtx, row); err != nil {
+			return fmt.Errorf("update airdrop row %d: %w", row.RowNo, err)
+		}
+	}
+
+	// 等待收據：成功才算 confirmed，revert 的列標為 failed，resume 時重送
+	pending := 0
+	for _, row := range waiting {
+		if ctx.Err() != nil {
+			pending++
+			continue
+		}
+		waitCtx, cancel := context.WithTimeout(ctx, s.timeouts.Tx)
+		receipt, err := s.client.WaitMined(waitCtx, row.TxHash)
+		cancel()
+		switch {
+		case err != nil:
+			pending++
+			slog.WarnContext(ctx, "[nft] airdrop row not confirmed yet", "id", id, "row", row.RowNo, "tx", row.TxHash, "err", err)
+			continue
+		case receipt.Status == types.ReceiptStatusSuccessful:
+			row.Status = models.AirdropRowConfirmed
+			row.Error = ""
+		default:
+			row.Status = models.AirdropRowFailed
+			row.Error = "transaction reverted"
+			failed++
+			slog.ErrorContext(ctx, "[nft] airdrop row reverted", "id", id, "row", row.RowNo, "tx", row.TxHash)
+		}
+		if err := s.DB.UpdateAirdropRow(dbCtx, row); err != nil {
+			return fmt.Errorf("update airdrop row %d: %w", row.RowNo, err)
+		}
+	}
+
+	status := models.AirdropDone
+	if failed > 0 || pending > 0 || ctx.Err() != nil {
+		status = models.AirdropPartial
+	}
+	if ctx.Err() != nil {
+		slog.WarnContext(ctx, "[nft] airdrop interrupted", "id", id, "err", ctx.Err())
+	}
+	slog.InfoContext(ctx, "[nft] airdrop finished", "id", id, "status", status, "failed", failed, "unconfirmed", pending)
+	// 中斷時 ctx 已取消，仍要記下狀態才能 resume
+	return s.DB.UpdateAirdropStatus(dbCtx, id, status)
+}
+
+// sentTxState 已送出交易在節點上的狀態
+type sentTxState int
+
+const (
+	txUnknown   sentTxState = iota // 節點不認得（未送達或已被丟棄），可以重送
+	txPending                      // 在 mempool 或已上鏈但收據尚未可查
+	txSucceeded                    // 上鏈且成功
+	txReverted                     // 上鏈但 revert
+)
+
+func (s *Service) sentTxState(ctx context.Context, hash gethcommon.Hash) (sentTxState, error) {
+	backend := s.client.Backend()
+	receipt, err := backend.TransactionReceipt(ctx, hash)
+	if err == nil {
+		if receipt.Status == types.ReceiptStatusSuccessful {
+			return txSucceeded, nil
+		}
+		return txReverted, nil
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return txUnknown, fmt.Errorf("get receipt: %w", err)
+	}
+	_, _, err = backend.TransactionByHash(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return txUnknown, nil
+	}
+	if err != nil {
+		return txUnknown, fmt.Errorf("get transaction: %w", err)
+	}
+	return txPending, nil
+}
@@ -0,0 +1,316 @@
+package nft
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/wkchen007/nftweb-back/internal/models"
+)
+
+var (
+	ErrAssignmentExists  = errors.New("assignment already committed")
+	ErrNoAssignment      = errors.New("assignment not committed")
+	ErrSaleStarted       = errors.New("sale already started, cannot commit assignment")
+	ErrCommitmentInvalid = errors.New("seed and mapping do not match commitment")
+	ErrNotOpened         = errors.New("blind box not opened yet, cannot reveal assignment")
+)
+
+// DrawAssignment 以 seed 決定的加權抽取，依序為 tokenId 0..n-1 抽出商品：
+// 商品依 id 由小到大排列，每個商品的區間大小為 weight * 剩餘份數（初始為 supply），
+// x = sha256(seed || uint64be(tokenId)) mod 區間總和，落在哪個區間就抽中哪個商品並扣一份
+func DrawAssignment(seed []byte, pool []models.PoolItem, n int) ([]int, error) {
+	items := append([]models.PoolItem(nil), pool...)
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	buf := make([]byte, len(seed)+8)
+	copy(buf, seed)
+	mapping := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		total := int64(0)
+		for _, item := range items {
+			total += int64(item.Weight) * int64(item.Supply)
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("pool exhausted at token %d", i)
+		}
+
+		binary.BigEndian.PutUint64(buf[len(seed):], uint64(i))
+		h := sha256.Sum256(buf)
+		x := new(big.Int).Mod(new(big.Int).SetBytes(h[:]), big.NewInt(total)).Int64()
+		for k := range items {
+			x -= int64(items[k].Weight) * int64(items[k].Supply)
+			if x < 0 {
+				mapping = append(mapping, items[k].ID)
+				items[k].Supply--
+				break
+			}
+		}
+	}
+	return mapping, nil
+}
+
+// catalogPool 由商品目錄建立分配池（demo = 0 的盲盒圖與權重 0 的商品不參與）
+func catalogPool(items []*models.NFT) []models.PoolItem {
+	pool := []models.PoolItem{}
+	for _, item := range items {
+		if item.Demo && item.Weight > 0 && item.Supply > 0 {
+			pool = append(pool, models.PoolItem{ID: item.ID, Rarity: item.Rarity, Weight: item.Weight, Supply: item.Supply})
+		}
+	}
+	sort.Slice(pool, func(i, j int) bool { return pool[i].ID < pool[j].ID })
+	return pool
+}
+
+// AssignmentCommitment = hex(sha256(seedHex + ":" + pool + ":" + mapping 以逗號串接))，
+// pool 依 id 排序、每項為 id/rarity/weight/supply 以 "/" 串接後再以逗號串接
+func AssignmentCommitment(seedHex string, pool []models.PoolItem, mapping []int) string {
+	items := append([]models.PoolItem(nil), pool...)
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	p := make([]string, len(items))
+	for i, item := range items {
+		p[i] = fmt.Sprintf("%d/%s/%d/%d", item.ID, item.Rarity, item.Weight, item.Supply)
+	}
+	m := make([]string, len(mapping))
+	for i, id := range mapping {
+		m[i] = strconv.Itoa(id)
+	}
+	h := sha256.Sum256([]byte(seedHex + ":" + strings.Join(p, ",") + ":" + strings.Join(m, ",")))
+	return hex.EncodeToString(h[:])
+}
+
+// VerifyAssignment 檢查公開的 seed、pool 與 mapping 是否符合事先公布的 commitment，
+// 並以 seed 與 pool 重算 mapping，確認 mapping 確實由 seed 抽出
+func VerifyAssignment(a models.Assignment) error {
+	if a.Seed == "" {
+		return ErrNoAssignment
+	}
+	if AssignmentCommitment(a.Seed, a.Pool, a.Mapping) != a.Commitment {
+		return ErrCommitmentInvalid
+	}
+	seed, err := hex.DecodeString(a.Seed)
+	if err != nil {
+		return fmt.Errorf("%w: invalid seed", ErrCommitmentInvalid)
+	}
+	mapping, err := DrawAssignment(seed, a.Pool, len(a.Mapping))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCommitmentInvalid, err)
+	}
+	for i := range mapping {
+		if mapping[i] != a.Mapping[i] {
+			return fmt.Errorf("%w: token %d", ErrCommitmentInvalid, i)
+		}
+	}
+	return nil
+}
+
+// CommitAssignment 在開賣前產生 seed、依權重抽出 tokenId → 商品 id，只公開 commitment
+func (s *Service) CommitAssignment(ctx context.Context) (models.Assignment, error) {
+	if _, err := s.DB.GetAssignment(ctx, s.con.Address().Hex()); err == nil {
+		return models.Assignment{}, ErrAssignmentExists
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return models.Assignment{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
+	defer cancel()
+
+	opts := &bind.CallOpts{Context: ctx}
+	counter, err := s.con.Counter(opts)
+	if err != nil {
+		return models.Assignment{}, fmt.Errorf("get counter: %w", err)
+	}
+	if counter.Sign() > 0 {
+		return models.Assignment{}, ErrSaleStarted
+	}
+	maxSupply, err := s.con.MaxSupply(opts)
+	if err != nil {
+		return models.Assignment{}, fmt.Errorf("get maxSupply: %w", err)
+	}
+
+	items, err := s.DB.CatalogItems(ctx, s.col.Catalog)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		return models.Assignment{}, fmt.Errorf("generate seed: %w", err)
+	}
+	pool := catalogPool(items)
+	mapping, err := DrawAssignment(seed, pool, int(maxSupply.Int64()))
+	if err != nil {
+		return models.Assignment{}, fmt.Errorf("catalog supply is less than maxSupply %s: %w", maxSupply, err)
+	}
+	a := models.Assignment{
+		Contract: s.con.Address().Hex(),
+		Seed:     hex.EncodeToString(seed),
+		Pool:     pool,
+		Mapping:  mapping,
+		Status:   models.AssignmentCommitted,
+	}
+	a.Commitment = AssignmentCommitment(a.Seed, a.Pool, a.Mapping)
+
+	a.ID, err = s.DB.InsertAssignment(ctx, a)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	slog.InfoContext(ctx, "[nft] assignment committed", "id", a.ID, "commitment", a.Commitment)
+
+	return a.Public(), nil
+}
+
+// RevealAssignment 合約開盒後公開 seed 與 mapping，之後 tokenId 依 mapping 對應商品；
+// 合約尚未開盒時回傳 ErrNotOpened，避免開盒前洩漏每個 token 對應的商品
+func (s *Service) RevealAssignment(ctx context.Context) (models.Assignment, error) {
+	a, err := s.assignment(ctx)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	if a == nil {
+		return models.Assignment{}, ErrNoAssignment
+	}
+	callCtx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
+	opened, err := s.isOpened(callCtx)
+	cancel()
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	if !opened {
+		return models.Assignment{}, ErrNotOpened
+	}
+	if err := VerifyAssignment(*a); err != nil {
+		return models.Assignment{}, err
+	}
+	if a.Status != models.AssignmentRevealed {
+		if err := s.DB.RevealAssignment(ctx, a.ID); err != nil {
+			return models.Assignment{}, err
+		}
+		now := time.Now()
+		a.Status = models.AssignmentRevealed
+		a.RevealedAt = &now
+		slog.InfoContext(ctx, "[nft] assignment revealed", "id", a.ID)
+	}
+	return *a, nil
+}
+
+// Assignment 回傳目前的分配（開盒前只有 commitment）
+func (s *Service) Assignment(ctx context.Context) (models.Assignment, error) {
+	a, err := s.assignment(ctx)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	if a == nil {
+		return models.Assignment{}, ErrNoAssignment
+	}
+	return a.Public(), nil
+}
+
+// AssignmentCommitted 合約是否已 commit 分配；commit 之後商品目錄不可再異動，否則 mapping 會對到不同的商品
+func (s *Service) AssignmentCommitted(ctx context.Context) (bool, error) {
+	a, err := s.assignment(ctx)
+	if err != nil {
+		return false, err
+	}
+	return a != nil, nil
+}
+
+// assignment 讀取合約的分配；尚未 commit 時回傳 nil
+func (s *Service) assignment(ctx context.Context) (*models.Assignment, error) {
+	a, err := s.DB.GetAssignment(ctx, s.con.Address().Hex())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get assignment: %w", err)
+	}
+	return a, nil
+}
+
+// tokenItems 依分配把 tokenId 轉成商品的 meta / image：
+// 已開盒走 mapping，未開盒一律回傳盲盒圖；沒有分配時沿用舊的 tokenId = 商品 id，是否開盒以合約為準
+func (s *Service) tokenItems(ctx context.Context, ids []int) ([]models.TokenItem, error) {
+	a, err := s.assignment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	revealed := a != nil && a.Status == models.AssignmentRevealed
+	if a == nil {
+		revealed, err = s.isOpened(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]models.TokenItem, 0, len(ids))
+	if !revealed {
+		box, err := s.DB.GetBoxItem(ctx, s.col.Catalog)
+		if err != nil {
+			return nil, fmt.Errorf("GetBoxItem: %w", err)
+		}
+		for _, id := range ids {
+			items = append(items, models.TokenItem{TokenID: strconv.Itoa(id), TokenURI: box.TokenURI, ImageURI: box.ImageURI})
+		}
+		return items, nil
+	}
+
+	catalogIDs := ids
+	if a != nil {
+		catalogIDs = make([]int, len(ids))
+		for i, id := range ids {
+			if id < 0 || id >= len(a.Mapping) {
+				return nil, fmt.Errorf("token %d is not in assignment", id)
+			}
+			catalogIDs[i] = a.Mapping[id]
+		}
+	}
+	if len(catalogIDs) == 0 {
+		return items, nil
+	}
+
+	rows, err := s.DB.GetTokenItem(ctx, s.col.Catalog, catalogIDs)
+	if err != nil {
+		return nil, fmt.Errorf("GetTokenItem: %w", err)
+	}
+	byCatalog := make(map[string]models.TokenItem, len(rows))
+	for _, row := range rows {
+		byCatalog[row.TokenID] = row
+	}
+	for i, id := range ids {
+		row, ok := byCatalog[strconv.Itoa(catalogIDs[i])]
+		if !ok {
+			return nil, fmt.Errorf("catalog item %d not found for token %d", catalogIDs[i], id)
+		}
+		row.TokenID = strconv.Itoa(id)
+		items = append(items, row)
+	}
+	return items, nil
+}
+
+// TokenMetadata 回傳單一 tokenId 經分配後的 meta / image 路徑
+func (s *Service) TokenMetadata(ctx context.Context, tokenID int) (models.TokenItem, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
+	defer cancel()
+
+	if _, err := s.con.OwnerOf(&bind.CallOpts{Context: ctx}, big.NewInt(int64(tokenID))); err != nil {
+		return models.TokenItem{}, notFound(err, "token %d not minted", tokenID)
+	}
+	items, err := s.tokenItems(ctx, []int{tokenID})
+	if err != nil {
+		return models.TokenItem{}, err
+	}
+	return items[0], nil
+}
@@ -0,0 +1,156 @@
+package nft
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/wkchen007/nftweb-back/internal/models"
+)
+
+var testPool = []models.PoolItem{
+	{ID: 3, Rarity: "legendary", Weight: 1, Supply: 1},
+	{ID: 1, Rarity: "common", Weight: 10, Supply: 5},
+	{ID: 2, Rarity: "rare", Weight: 3, Supply: 2},
+}
+
+func TestDrawAssignment(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, 32)
+	tests := []struct {
+		name    string
+		pool    []models.PoolItem
+		n       int
+		wantErr bool
+	}{
+		{"partial", testPool, 4, false},
+		{"whole supply", testPool, 8, false},
+		{"exceeds supply", testPool, 9, true},
+		{"zero weight only", []models.PoolItem{{ID: 1, Weight: 0, Supply: 5}}, 1, true},
+		{"empty pool", nil, 1, true},
+		{"nothing to draw", nil, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := DrawAssignment(seed, tt.pool, tt.n)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("DrawAssignment = %v, want error", mapping)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DrawAssignment: %v", err)
+			}
+			if len(mapping) != tt.n {
+				t.Fatalf("len(mapping) = %d, want %d", len(mapping), tt.n)
+			}
+
+			// 每個商品抽中的次數不超過 supply
+			supply := map[int]int{}
+			for _, item := range tt.pool {
+				supply[item.ID] = item.Supply
+			}
+			for _, id := range mapping {
+				supply[id]--
+				if supply[id] < 0 {
+					t.Fatalf("item %d drawn more than its supply: %v", id, mapping)
+				}
+			}
+
+			// 同樣的 seed 與 pool（不論順序）得到同樣的結果
+			again, err := DrawAssignment(seed, slices.Clone(tt.pool), tt.n)
+			if err != nil || !slices.Equal(again, mapping) {
+				t.Fatalf("not deterministic: %v vs %v (err %v)", mapping, again, err)
+			}
+			reversed := slices.Clone(tt.pool)
+			slices.Reverse(reversed)
+			if again, _ := DrawAssignment(seed, reversed, tt.n); !slices.Equal(again, mapping) {
+				t.Fatalf("depends on pool order: %v vs %v", mapping, again)
+			}
+		})
+	}
+
+	// 呼叫端的 pool 不會被扣減
+	pool := slices.Clone(testPool)
+	if _, err := DrawAssignment(seed, pool, 8); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pool, testPool) {
+		t.Fatalf("pool modified: %v", pool)
+	}
+}
+
+func TestVerifyAssignment(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x07}, 32)
+	mapping, err := DrawAssignment(seed, testPool, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := models.Assignment{
+		Seed:    hex.EncodeToString(seed),
+		Pool:    testPool,
+		Mapping: mapping,
+	}
+	valid.Commitment = AssignmentCommitment(valid.Seed, valid.Pool, valid.Mapping)
+
+	// 換掉 mapping 前兩個不同的商品，commitment 照新內容重算（模擬連 commitment 一起偽造）
+	swapped := slices.Clone(mapping)
+	for i := 1; i < len(swapped); i++ {
+		if swapped[i] != swapped[0] {
+			swapped[0], swapped[i] = swapped[i], swapped[0]
+			break
+		}
+	}
+	otherPool := slices.Clone(testPool)
+	otherPool[1].Weight = 1
+
+	tests := []struct {
+		name   string
+		modify func(a *models.Assignment)
+		want   error
+	}{
+		{"valid", func(a *models.Assignment) {}, nil},
+		{"no seed", func(a *models.Assignment) { a.Seed = "" }, ErrNoAssignment},
+		{"wrong commitment", func(a *models.Assignment) { a.Commitment = AssignmentCommitment("00", a.Pool, a.Mapping) }, ErrCommitmentInvalid},
+		{"tampered mapping", func(a *models.Assignment) { a.Mapping = swapped }, ErrCommitmentInvalid},
+		{"tampered pool", func(a *models.Assignment) { a.Pool = otherPool }, ErrCommitmentInvalid},
+		{"recommitted mapping", func(a *models.Assignment) {
+			a.Mapping = swapped
+			a.Commitment = AssignmentCommitment(a.Seed, a.Pool, a.Mapping)
+		}, ErrCommitmentInvalid},
+		{"recommitted pool", func(a *models.Assignment) {
+			a.Pool = otherPool
+			a.Commitment = AssignmentCommitment(a.Seed, a.Pool, a.Mapping)
+		}, ErrCommitmentInvalid},
+		{"invalid seed hex", func(a *models.Assignment) {
+			a.Seed = "zz"
+			a.Commitment = AssignmentCommitment(a.Seed, a.Pool, a.Mapping)
+		}, ErrCommitmentInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := valid
+			tt.modify(&a)
+			err := VerifyAssignment(a)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("VerifyAssignment: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("VerifyAssignment err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignmentCommitmentPoolOrder(t *testing.T) {
+	reversed := slices.Clone(testPool)
+	slices.Reverse(reversed)
+	if AssignmentCommitment("ab", testPool, []int{1, 2}) != AssignmentCommitment("ab", reversed, []int{1, 2}) {
+		t.Fatal("commitment depends on pool order")
+	}
+}
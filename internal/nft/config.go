@@ -1,18 +1,35 @@
 package nft
 
 import (
+	"errors"
+	"fmt"
+	"math/big"
 	"os"
+	"regexp"
+	"strings"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultSlug 預設合集（config 的 nft 區塊）的 slug 與商品目錄命名空間
+const DefaultSlug = "default"
+
+// CollectionConfig 單一合集的合約設定；Catalog 為商品目錄命名空間（nft.collection 欄位）
+type CollectionConfig struct {
+	Slug            string `yaml:"slug"`
+	Chain           uint64 `yaml:"chain"` // chain id，0 代表預設鏈
+	ContractAddress string `yaml:"contractAddress"`
+	ContractTxHash  string `yaml:"contractTxHash"`
+	ABIPath         string `yaml:"abiPath"`
+	MaxScanTokenID  int64  `yaml:"maxScanTokenID"`
+	Catalog         string `yaml:"catalog"`
+	MintPriceWei    string `yaml:"mintPriceWei"` // 合約的 mint 單價（wei），合約沒有提供 view，預設 0.01 ether
+}
+
 type Config struct {
-	NFT struct {
-		ContractAddress string `yaml:"contractAddress"`
-		ContractTxHash  string `yaml:"contractTxHash"`
-		ABIPath         string `yaml:"abiPath"`
-		MaxScanTokenID  int64  `yaml:"maxScanTokenID"`
-	} `yaml:"nft"`
+	NFT         CollectionConfig   `yaml:"nft"`         // 預設合集，/nft 路由使用
+	Collections []CollectionConfig `yaml:"collections"` // 其他合集，以 /collections/{slug} 存取
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -26,3 +43,93 @@ func LoadConfig(path string) (*Config, error) {
 	}
 	return &cfg, nil
 }
+
+// All 回傳所有合集（預設合集在第一個），未填的 slug / catalog 補上預設值
+func (c *Config) All() []CollectionConfig {
+	def := c.NFT
+	if def.Slug == "" {
+		def.Slug = DefaultSlug
+	}
+	all := []CollectionConfig{def.withDefaults()}
+	for _, col := range c.Collections {
+		all = append(all, col.withDefaults())
+	}
+	return all
+}
+
+func (c CollectionConfig) withDefaults() CollectionConfig {
+	if c.Catalog == "" {
+		c.Catalog = c.Slug
+	}
+	if c.MintPriceWei == "" {
+		c.MintPriceWei = MintPriceWei.String()
+	}
+	return c
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// Validate 檢查每個合集的設定格式與 ABI 檔案，且 slug / 合約地址不可重複
+func (c *Config) Validate() error {
+	var errs []error
+	slugs := map[string]bool{}
+	addrs := map[gethcommon.Address]bool{}
+	for i, col := range c.All() {
+		field := "nft"
+		if i > 0 {
+			field = fmt.Sprintf("collections[%d]", i-1)
+		}
+		if err := col.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+			continue
+		}
+		if slugs[col.Slug] {
+			errs = append(errs, fmt.Errorf("%s: duplicate slug %q", field, col.Slug))
+		}
+		slugs[col.Slug] = true
+		addr := gethcommon.HexToAddress(col.ContractAddress)
+		if addrs[addr] {
+			errs = append(errs, fmt.Errorf("%s: duplicate contractAddress %s", field, addr.Hex()))
+		}
+		addrs[addr] = true
+	}
+	return errors.Join(errs...)
+}
+
+// Validate 檢查單一合集的合約設定格式，並確認 ABI 檔案存在
+func (c CollectionConfig) Validate() error {
+	var errs []error
+	if !slugPattern.MatchString(c.Slug) {
+		errs = append(errs, fmt.Errorf("slug %q must be lowercase letters, digits or '-'", c.Slug))
+	}
+	if !gethcommon.IsHexAddress(c.ContractAddress) {
+		errs = append(errs, fmt.Errorf("contractAddress is not a valid address"))
+	}
+	if !strings.HasPrefix(c.ContractTxHash, "0x") || len(c.ContractTxHash) != 66 {
+		errs = append(errs, fmt.Errorf("contractTxHash is not a valid tx hash"))
+	}
+	if c.ABIPath == "" {
+		errs = append(errs, fmt.Errorf("abiPath is required"))
+	} else if _, err := os.Stat(c.ABIPath); err != nil {
+		errs = append(errs, fmt.Errorf("abiPath: %w", err))
+	}
+	if c.MaxScanTokenID < 0 {
+		errs = append(errs, fmt.Errorf("maxScanTokenID must be >= 0"))
+	}
+	if c.Catalog == "" || len(c.Catalog) > 50 {
+		errs = append(errs, fmt.Errorf("catalog must be 1-50 characters"))
+	}
+	if _, err := c.mintPrice(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// mintPrice 解析 MintPriceWei，必須為正整數
+func (c CollectionConfig) mintPrice() (*big.Int, error) {
+	price, ok := new(big.Int).SetString(c.MintPriceWei, 10)
+	if !ok || price.Sign() <= 0 {
+		return nil, fmt.Errorf("mintPriceWei %q must be a positive integer", c.MintPriceWei)
+	}
+	return price, nil
+}
@@ -0,0 +1,238 @@
+package nft
+
+//go:generate abigen --abi ../../configs/nftABI.json --pkg mynft --type MyNFT --out mynft/mynft.go
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/wkchen007/nftweb-back/internal/nft/mynft"
+)
+
+// MintPriceWei 合約 mint 的預設單價（0.01 ether），合集可用 mintPriceWei 覆寫；合約要求 msg.value == price * amount
+var MintPriceWei = big.NewInt(1e16)
+
+// NFTContract 對應 MyNFT 合約（contract/ERC721.sol）ABI 的型別化介面，
+// 涵蓋所有 view / 交易方法與事件，讓 Service 不必再自行處理 []interface{}
+type NFTContract interface {
+	Address() gethcommon.Address
+
+	// view
+	Name(opts *bind.CallOpts) (string, error)
+	Symbol(opts *bind.CallOpts) (string, error)
+	BalanceOf(opts *bind.CallOpts, owner gethcommon.Address) (*big.Int, error)
+	OwnerOf(opts *bind.CallOpts, tokenID *big.Int) (gethcommon.Address, error)
+	GetApproved(opts *bind.CallOpts, tokenID *big.Int) (gethcommon.Address, error)
+	IsApprovedForAll(opts *bind.CallOpts, owner, operator gethcommon.Address) (bool, error)
+	TokenURI(opts *bind.CallOpts, tokenID *big.Int) (string, error)
+	Counter(opts *bind.CallOpts) (*big.Int, error)
+	MaxSupply(opts *bind.CallOpts) (*big.Int, error)
+	SupportsInterface(opts *bind.CallOpts, interfaceID [4]byte) (bool, error)
+
+	// 交易
+	Mint(opts *bind.TransactOpts, to gethcommon.Address, amount *big.Int) (*types.Transaction, error)
+	OpenBlindBox(opts *bind.TransactOpts) (*types.Transaction, error)
+	Withdraw(opts *bind.TransactOpts) (*types.Transaction, error)
+	Approve(opts *bind.TransactOpts, to gethcommon.Address, tokenID *big.Int) (*types.Transaction, error)
+	SetApprovalForAll(opts *bind.TransactOpts, operator gethcommon.Address, approved bool) (*types.Transaction, error)
+	TransferFrom(opts *bind.TransactOpts, from, to gethcommon.Address, tokenID *big.Int) (*types.Transaction, error)
+	// SafeTransferFrom data 為 nil 時走三參數版本，否則走帶 data 的 overload
+	SafeTransferFrom(opts *bind.TransactOpts, from, to gethcommon.Address, tokenID *big.Int, data []byte) (*types.Transaction, error)
+
+	// 事件（nil 的 filter 參數代表不限制）
+	FilterTransfer(opts *bind.FilterOpts, from, to []gethcommon.Address, tokenID []*big.Int) ([]TransferEvent, error)
+	FilterApproval(opts *bind.FilterOpts, owner, approved []gethcommon.Address, tokenID []*big.Int) ([]ApprovalEvent, error)
+	FilterApprovalForAll(opts *bind.FilterOpts, owner, operator []gethcommon.Address) ([]ApprovalForAllEvent, error)
+}
+
+// TransferEvent 對應 Transfer(address indexed from, address indexed to, uint256 indexed tokenId)
+type TransferEvent struct {
+	From    gethcommon.Address
+	To      gethcommon.Address
+	TokenID *big.Int
+	Raw     types.Log
+}
+
+// ApprovalEvent 對應 Approval(address indexed owner, address indexed approved, uint256 indexed tokenId)
+type ApprovalEvent struct {
+	Owner    gethcommon.Address
+	Approved gethcommon.Address
+	TokenID  *big.Int
+	Raw      types.Log
+}
+
+// ApprovalForAllEvent 對應 ApprovalForAll(address indexed owner, address indexed operator, bool approved)
+type ApprovalForAllEvent struct {
+	Owner    gethcommon.Address
+	Operator gethcommon.Address
+	Approved bool
+	Raw      types.Log
+}
+
+// gethContract 以 abigen 產生的 mynft 綁定實作 NFTContract
+type gethContract struct {
+	addr gethcommon.Address
+	b    *mynft.MyNFT
+}
+
+// NewGethContract 建立 go-ethereum 版本的 NFTContract。
+// parsed 為設定檔 abiPath 載入的 ABI，會先確認與產生綁定時的 ABI 相容，避免合約更新後到執行期才出錯
+func NewGethContract(addr gethcommon.Address, parsed abi.ABI, backend bind.ContractBackend) (NFTContract, error) {
+	if err := checkABICompat(parsed); err != nil {
+		return nil, err
+	}
+	b, err := mynft.NewMyNFT(addr, backend)
+	if err != nil {
+		return nil, fmt.Errorf("bind contract: %w", err)
+	}
+	return &gethContract{addr: addr, b: b}, nil
+}
+
+// checkABICompat 確認 parsed 內有綁定用到的每個方法與事件，且 selector / topic 相同
+func checkABICompat(parsed abi.ABI) error {
+	want, err := mynft.MyNFTMetaData.GetAbi()
+	if err != nil {
+		return fmt.Errorf("load binding abi: %w", err)
+	}
+	for name, m := range want.Methods {
+		got, ok := parsed.Methods[name]
+		if !ok {
+			return fmt.Errorf("abi missing method %s", m.Sig)
+		}
+		if got.Sig != m.Sig || got.StateMutability != m.StateMutability {
+			return fmt.Errorf("abi method mismatch: %s, binding expects %s", got.Sig, m.Sig)
+		}
+	}
+	for name, e := range want.Events {
+		got, ok := parsed.Events[name]
+		if !ok {
+			return fmt.Errorf("abi missing event %s", e.Sig)
+		}
+		if got.ID != e.ID {
+			return fmt.Errorf("abi event mismatch: %s, binding expects %s", got.Sig, e.Sig)
+		}
+	}
+	return nil
+}
+
+func (c *gethContract) Address() gethcommon.Address { return c.addr }
+
+func (c *gethContract) Name(opts *bind.CallOpts) (string, error) {
+	return c.b.Name(opts)
+}
+
+func (c *gethContract) Symbol(opts *bind.CallOpts) (string, error) {
+	return c.b.Symbol(opts)
+}
+
+func (c *gethContract) BalanceOf(opts *bind.CallOpts, owner gethcommon.Address) (*big.Int, error) {
+	return c.b.BalanceOf(opts, owner)
+}
+
+func (c *gethContract) OwnerOf(opts *bind.CallOpts, tokenID *big.Int) (gethcommon.Address, error) {
+	return c.b.OwnerOf(opts, tokenID)
+}
+
+func (c *gethContract) GetApproved(opts *bind.CallOpts, tokenID *big.Int) (gethcommon.Address, error) {
+	return c.b.GetApproved(opts, tokenID)
+}
+
+func (c *gethContract) IsApprovedForAll(opts *bind.CallOpts, owner, operator gethcommon.Address) (bool, error) {
+	return c.b.IsApprovedForAll(opts, owner, operator)
+}
+
+func (c *gethContract) TokenURI(opts *bind.CallOpts, tokenID *big.Int) (string, error) {
+	return c.b.TokenURI(opts, tokenID)
+}
+
+func (c *gethContract) Counter(opts *bind.CallOpts) (*big.Int, error) {
+	return c.b.Counter(opts)
+}
+
+func (c *gethContract) MaxSupply(opts *bind.CallOpts) (*big.Int, error) {
+	return c.b.MaxSupply(opts)
+}
+
+func (c *gethContract) SupportsInterface(opts *bind.CallOpts, interfaceID [4]byte) (bool, error) {
+	return c.b.SupportsInterface(opts, interfaceID)
+}
+
+func (c *gethContract) Mint(opts *bind.TransactOpts, to gethcommon.Address, amount *big.Int) (*types.Transaction, error) {
+	return c.b.Mint(opts, to, amount)
+}
+
+func (c *gethContract) OpenBlindBox(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return c.b.OpenBlindBox(opts)
+}
+
+func (c *gethContract) Withdraw(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return c.b.Withdraw(opts)
+}
+
+func (c *gethContract) Approve(opts *bind.TransactOpts, to gethcommon.Address, tokenID *big.Int) (*types.Transaction, error) {
+	return c.b.Approve(opts, to, tokenID)
+}
+
+func (c *gethContract) SetApprovalForAll(opts *bind.TransactOpts, operator gethcommon.Address, approved bool) (*types.Transaction, error) {
+	return c.b.SetApprovalForAll(opts, operator, approved)
+}
+
+func (c *gethContract) TransferFrom(opts *bind.TransactOpts, from, to gethcommon.Address, tokenID *big.Int) (*types.Transaction, error) {
+	return c.b.TransferFrom(opts, from, to, tokenID)
+}
+
+func (c *gethContract) SafeTransferFrom(opts *bind.TransactOpts, from, to gethcommon.Address, tokenID *big.Int, data []byte) (*types.Transaction, error) {
+	if data == nil {
+		return c.b.SafeTransferFrom(opts, from, to, tokenID)
+	}
+	return c.b.SafeTransferFrom0(opts, from, to, tokenID, data)
+}
+
+func (c *gethContract) FilterTransfer(opts *bind.FilterOpts, from, to []gethcommon.Address, tokenID []*big.Int) ([]TransferEvent, error) {
+	it, err := c.b.FilterTransfer(opts, from, to, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var out []TransferEvent
+	for it.Next() {
+		e := it.Event
+		out = append(out, TransferEvent{From: e.From, To: e.To, TokenID: e.TokenId, Raw: e.Raw})
+	}
+	return out, it.Error()
+}
+
+func (c *gethContract) FilterApproval(opts *bind.FilterOpts, owner, approved []gethcommon.Address, tokenID []*big.Int) ([]ApprovalEvent, error) {
+	it, err := c.b.FilterApproval(opts, owner, approved, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var out []ApprovalEvent
+	for it.Next() {
+		e := it.Event
+		out = append(out, ApprovalEvent{Owner: e.Owner, Approved: e.Approved, TokenID: e.TokenId, Raw: e.Raw})
+	}
+	return out, it.Error()
+}
+
+func (c *gethContract) FilterApprovalForAll(opts *bind.FilterOpts, owner, operator []gethcommon.Address) ([]ApprovalForAllEvent, error) {
+	it, err := c.b.FilterApprovalForAll(opts, owner, operator)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var out []ApprovalForAllEvent
+	for it.Next() {
+		e := it.Event
+		out = append(out, ApprovalForAllEvent{Owner: e.Owner, Operator: e.Operator, Approved: e.Approved, Raw: e.Raw})
+	}
+	return out, it.Error()
+}
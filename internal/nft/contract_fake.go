@@ -0,0 +1,456 @@
+package nft
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	fakeBoxURI   = "ipfs://bafkreicnovsrbhko6exqtctuhqyg6nvloulmydgu4onfzpp4uqkm7hxle4/"
+	fakeRevealed = "ipfs://bafybeiectyzmhyq5mjqvdbk3x2g77zbs7odwdkfqzuyzfpey3bxweirasu/"
+)
+
+// FakeContract 記憶體版 NFTContract，行為對齊 contract/ERC721.sol，
+// 讓 Service 的邏輯不需要 RPC 節點即可測試。每筆交易視為一個新區塊。
+// opts.NoSend 的交易只檢查 revert 條件，狀態在以 SendTransaction 送出時才改變（與真實合約一樣送出才生效）
+type FakeContract struct {
+	mu sync.Mutex
+
+	addr      gethcommon.Address
+	owner     gethcommon.Address // 合約 owner（onlyOwner）
+	name      string
+	symbol    string
+	maxSupply uint64
+	counter   uint64
+	opened    bool
+	balance   *big.Int // 合約持有的 ETH（wei）
+
+	owners    map[uint64]gethcommon.Address
+	approvals map[uint64]gethcommon.Address
+	operators map[gethcommon.Address]map[gethcommon.Address]bool
+
+	block     uint64
+	nonce     uint64
+	pending   map[string]fakeCall // NoSend 的交易，以 calldata 對應
+	transfers []TransferEvent
+	approves  []ApprovalEvent
+	approveAl []ApprovalForAllEvent
+}
+
+// NewFakeContract 建立一個由 owner 部署、上限 maxSupply 的假合約
+func NewFakeContract(addr, owner gethcommon.Address, maxSupply uint64) *FakeContract {
+	return &FakeContract{
+		addr:      addr,
+		owner:     owner,
+		name:      "MyNFT",
+		symbol:    "MNFT",
+		maxSupply: maxSupply,
+		balance:   new(big.Int),
+		owners:    map[uint64]gethcommon.Address{},
+		approvals: map[uint64]gethcommon.Address{},
+		operators: map[gethcommon.Address]map[gethcommon.Address]bool{},
+		pending:   map[string]fakeCall{},
+	}
+}
+
+// fakeCall 一筆交易的 revert 條件與生效時的狀態變更
+type fakeCall struct {
+	check func() error
+	apply func(tx *types.Transaction)
+}
+
+// ContractBalance 回傳合約目前持有的 wei（真實環境由 BalanceAt 取得）
+func (f *FakeContract) ContractBalance() *big.Int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return new(big.Int).Set(f.balance)
+}
+
+// BlockNumber 回傳目前的假區塊高度
+func (f *FakeContract) BlockNumber() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.block
+}
+
+func (f *FakeContract) Address() gethcommon.Address { return f.addr }
+
+func (f *FakeContract) Name(opts *bind.CallOpts) (string, error) {
+	return f.name, nil
+}
+
+func (f *FakeContract) Symbol(opts *bind.CallOpts) (string, error) {
+	return f.symbol, nil
+}
+
+func (f *FakeContract) BalanceOf(opts *bind.CallOpts, owner gethcommon.Address) (*big.Int, error) {
+	if owner == (gethcommon.Address{}) {
+		return nil, fmt.Errorf("execution reverted: ERC721InvalidOwner(%s)", owner.Hex())
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int64
+	for _, o := range f.ownersAt(opts) {
+		if o == owner {
+			n++
+		}
+	}
+	return big.NewInt(n), nil
+}
+
+func (f *FakeContract) OwnerOf(opts *bind.CallOpts, tokenID *big.Int) (gethcommon.Address, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o, ok := f.ownersAt(opts)[tokenID.Uint64()]
+	if !tokenID.IsUint64() || !ok {
+		return gethcommon.Address{}, fmt.Errorf("execution reverted: ERC721NonexistentToken(%s)", tokenID)
+	}
+	return o, nil
+}
+
+func (f *FakeContract) GetApproved(opts *bind.CallOpts, tokenID *big.Int) (gethcommon.Address, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.owners[tokenID.Uint64()]; !tokenID.IsUint64() || !ok {
+		return gethcommon.Address{}, fmt.Errorf("execution reverted: ERC721NonexistentToken(%s)", tokenID)
+	}
+	return f.approvals[tokenID.Uint64()], nil
+}
+
+func (f *FakeContract) IsApprovedForAll(opts *bind.CallOpts, owner, operator gethcommon.Address) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.operators[owner][operator], nil
+}
+
+// TokenURI 與合約一致：未開盲盒前一律回傳盲盒 URI，且不檢查 token 是否存在
+func (f *FakeContract) TokenURI(opts *bind.CallOpts, tokenID *big.Int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.opened {
+		return fakeBoxURI, nil
+	}
+	return fmt.Sprintf("%s%s.json", fakeRevealed, tokenID.String()), nil
+}
+
+func (f *FakeContract) Counter(opts *bind.CallOpts) (*big.Int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return new(big.Int).SetUint64(uint64(len(f.ownersAt(opts)))), nil
+}
+
+func (f *FakeContract) MaxSupply(opts *bind.CallOpts) (*big.Int, error) {
+	return new(big.Int).SetUint64(f.maxSupply), nil
+}
+
+func (f *FakeContract) SupportsInterface(opts *bind.CallOpts, interfaceID [4]byte) (bool, error) {
+	switch interfaceID {
+	case [4]byte{0x01, 0xff, 0xc9, 0xa7}, // ERC165
+		[4]byte{0x80, 0xac, 0x58, 0xcd}, // ERC721
+		[4]byte{0x5b, 0x5e, 0x13, 0x9f}: // ERC721Metadata
+		return true, nil
+	}
+	return false, nil
+}
+
+func (f *FakeContract) Mint(opts *bind.TransactOpts, to gethcommon.Address, amount *big.Int) (*types.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	value := txValue(opts)
+	return f.transact(opts, value, func() error {
+		if new(big.Int).Add(new(big.Int).SetUint64(f.counter), amount).Cmp(new(big.Int).SetUint64(f.maxSupply)) > 0 {
+			return fmt.Errorf("execution reverted: over max supply.")
+		}
+		if value.Cmp(new(big.Int).Mul(MintPriceWei, amount)) != 0 {
+			return fmt.Errorf("execution reverted: incorrect payment")
+		}
+		if to == (gethcommon.Address{}) {
+			return fmt.Errorf("execution reverted: ERC721InvalidReceiver(%s)", to.Hex())
+		}
+		return nil
+	}, func(tx *types.Transaction) {
+		for i := uint64(0); i < amount.Uint64(); i++ {
+			id := f.counter
+			f.owners[id] = to
+			f.logTransfer(tx, gethcommon.Address{}, to, id)
+			f.counter++
+		}
+		f.balance.Add(f.balance, value)
+	})
+}
+
+func (f *FakeContract) OpenBlindBox(opts *bind.TransactOpts) (*types.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.transact(opts, nil, func() error {
+		return f.onlyOwner(opts)
+	}, func(*types.Transaction) {
+		f.opened = true
+	})
+}
+
+func (f *FakeContract) Withdraw(opts *bind.TransactOpts) (*types.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.transact(opts, nil, func() error {
+		if err := f.onlyOwner(opts); err != nil {
+			return err
+		}
+		if f.balance.Sign() <= 0 {
+			return fmt.Errorf("execution reverted: no funds")
+		}
+		return nil
+	}, func(*types.Transaction) {
+		f.balance = new(big.Int)
+	})
+}
+
+func (f *FakeContract) Approve(opts *bind.TransactOpts, to gethcommon.Address, tokenID *big.Int) (*types.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := tokenID.Uint64()
+	return f.transact(opts, nil, func() error {
+		owner, ok := f.owners[id]
+		if !tokenID.IsUint64() || !ok {
+			return fmt.Errorf("execution reverted: ERC721NonexistentToken(%s)", tokenID)
+		}
+		sender := txSender(opts)
+		if sender != owner && !f.operators[owner][sender] {
+			return fmt.Errorf("execution reverted: ERC721InvalidApprover(%s)", sender.Hex())
+		}
+		return nil
+	}, func(tx *types.Transaction) {
+		f.approvals[id] = to
+		f.approves = append(f.approves, ApprovalEvent{Owner: f.owners[id], Approved: to, TokenID: new(big.Int).Set(tokenID), Raw: f.rawLog(tx)})
+	})
+}
+
+func (f *FakeContract) SetApprovalForAll(opts *bind.TransactOpts, operator gethcommon.Address, approved bool) (*types.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sender := txSender(opts)
+	return f.transact(opts, nil, func() error {
+		if operator == (gethcommon.Address{}) {
+			return fmt.Errorf("execution reverted: ERC721InvalidOperator(%s)", operator.Hex())
+		}
+		return nil
+	}, func(tx *types.Transaction) {
+		if f.operators[sender] == nil {
+			f.operators[sender] = map[gethcommon.Address]bool{}
+		}
+		f.operators[sender][operator] = approved
+		f.approveAl = append(f.approveAl, ApprovalForAllEvent{Owner: sender, Operator: operator, Approved: approved, Raw: f.rawLog(tx)})
+	})
+}
+
+func (f *FakeContract) TransferFrom(opts *bind.TransactOpts, from, to gethcommon.Address, tokenID *big.Int) (*types.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := tokenID.Uint64()
+	return f.transact(opts, nil, func() error {
+		if to == (gethcommon.Address{}) {
+			return fmt.Errorf("execution reverted: ERC721InvalidReceiver(%s)", to.Hex())
+		}
+		owner, ok := f.owners[id]
+		if !tokenID.IsUint64() || !ok {
+			return fmt.Errorf("execution reverted: ERC721NonexistentToken(%s)", tokenID)
+		}
+		sender := txSender(opts)
+		if sender != owner && f.approvals[id] != sender && !f.operators[owner][sender] {
+			return fmt.Errorf("execution reverted: ERC721InsufficientApproval(%s, %s)", sender.Hex(), tokenID)
+		}
+		if owner != from {
+			return fmt.Errorf("execution reverted: ERC721IncorrectOwner(%s, %s, %s)", from.Hex(), tokenID, owner.Hex())
+		}
+		return nil
+	}, func(tx *types.Transaction) {
+		delete(f.approvals, id)
+		f.owners[id] = to
+		f.logTransfer(tx, from, to, id)
+	})
+}
+
+// SafeTransferFrom 假合約沒有 onERC721Received 的接收方，行為等同 TransferFrom
+func (f *FakeContract) SafeTransferFrom(opts *bind.TransactOpts, from, to gethcommon.Address, tokenID *big.Int, data []byte) (*types.Transaction, error) {
+	return f.TransferFrom(opts, from, to, tokenID)
+}
+
+func (f *FakeContract) FilterTransfer(opts *bind.FilterOpts, from, to []gethcommon.Address, tokenID []*big.Int) ([]TransferEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []TransferEvent
+	for _, e := range f.transfers {
+		if inRange(opts, e.Raw.BlockNumber) && matchAddr(from, e.From) && matchAddr(to, e.To) && matchID(tokenID, e.TokenID) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *FakeContract) FilterApproval(opts *bind.FilterOpts, owner, approved []gethcommon.Address, tokenID []*big.Int) ([]ApprovalEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []ApprovalEvent
+	for _, e := range f.approves {
+		if inRange(opts, e.Raw.BlockNumber) && matchAddr(owner, e.Owner) && matchAddr(approved, e.Approved) && matchID(tokenID, e.TokenID) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *FakeContract) FilterApprovalForAll(opts *bind.FilterOpts, owner, operator []gethcommon.Address) ([]ApprovalForAllEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []ApprovalForAllEvent
+	for _, e := range f.approveAl {
+		if inRange(opts, e.Raw.BlockNumber) && matchAddr(owner, e.Owner) && matchAddr(operator, e.Operator) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// ownersAt 依 opts.BlockNumber 回傳當時的持有者；未指定區塊時回傳目前狀態（呼叫端需持有鎖）
+func (f *FakeContract) ownersAt(opts *bind.CallOpts) map[uint64]gethcommon.Address {
+	if opts == nil || opts.BlockNumber == nil || opts.BlockNumber.Uint64() >= f.block {
+		return f.owners
+	}
+	owners := map[uint64]gethcommon.Address{}
+	for _, e := range f.transfers {
+		if e.Raw.BlockNumber > opts.BlockNumber.Uint64() {
+			break
+		}
+		owners[e.TokenID.Uint64()] = e.To
+	}
+	return owners
+}
+
+func (f *FakeContract) onlyOwner(opts *bind.TransactOpts) error {
+	if txSender(opts) != f.owner {
+		return fmt.Errorf("execution reverted")
+	}
+	return nil
+}
+
+// transact 檢查 revert 條件後產生一筆假交易（calldata 為流水號）。opts.NoSend 時只記下 call，
+// 由 SendTransaction 送出時才改變狀態；否則立即生效（呼叫端需持有鎖）
+func (f *FakeContract) transact(opts *bind.TransactOpts, value *big.Int, check func() error, apply func(tx *types.Transaction)) (*types.Transaction, error) {
+	if err := check(); err != nil {
+		return nil, err
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	f.nonce++
+	data := binary.BigEndian.AppendUint64(nil, f.nonce)
+	tx := types.NewTx(&types.LegacyTx{Nonce: f.nonce, To: &f.addr, Value: value, Data: data})
+
+	call := fakeCall{check: check, apply: apply}
+	if opts != nil && opts.NoSend {
+		f.pending[hex.EncodeToString(data)] = call
+		return tx, nil
+	}
+	f.mine(tx, call)
+	return tx, nil
+}
+
+// SendTransaction 送出先前以 NoSend 產生的交易（calldata 需相同），重新檢查 revert 條件後生效，
+// 對應測試用 backend 的 SendTransaction
+func (f *FakeContract) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if tx.To() == nil || *tx.To() != f.addr {
+		return fmt.Errorf("tx %s is not sent to %s", tx.Hash().Hex(), f.addr.Hex())
+	}
+	key := hex.EncodeToString(tx.Data())
+	call, ok := f.pending[key]
+	if !ok {
+		return fmt.Errorf("unknown tx data %s", key)
+	}
+	delete(f.pending, key)
+	if err := call.check(); err != nil {
+		return err
+	}
+	f.mine(tx, call)
+	return nil
+}
+
+// mine 推進區塊高度並套用交易的狀態變更（呼叫端需持有鎖）
+func (f *FakeContract) mine(tx *types.Transaction, call fakeCall) {
+	f.block++
+	call.apply(tx)
+}
+
+func (f *FakeContract) rawLog(tx *types.Transaction) types.Log {
+	return types.Log{
+		Address:     f.addr,
+		BlockNumber: f.block,
+		TxHash:      tx.Hash(),
+		Index:       uint(len(f.transfers) + len(f.approves) + len(f.approveAl)),
+	}
+}
+
+func (f *FakeContract) logTransfer(tx *types.Transaction, from, to gethcommon.Address, id uint64) {
+	f.transfers = append(f.transfers, TransferEvent{From: from, To: to, TokenID: new(big.Int).SetUint64(id), Raw: f.rawLog(tx)})
+}
+
+func txSender(opts *bind.TransactOpts) gethcommon.Address {
+	if opts == nil {
+		return gethcommon.Address{}
+	}
+	return opts.From
+}
+
+func txValue(opts *bind.TransactOpts) *big.Int {
+	if opts == nil || opts.Value == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(opts.Value)
+}
+
+func inRange(opts *bind.FilterOpts, block uint64) bool {
+	if opts == nil {
+		return true
+	}
+	if block < opts.Start {
+		return false
+	}
+	return opts.End == nil || block <= *opts.End
+}
+
+func matchAddr(rule []gethcommon.Address, a gethcommon.Address) bool {
+	if len(rule) == 0 {
+		return true
+	}
+	for _, r := range rule {
+		if r == a {
+			return true
+		}
+	}
+	return false
+}
+
+func matchID(rule []*big.Int, id *big.Int) bool {
+	if len(rule) == 0 {
+		return true
+	}
+	for _, r := range rule {
+		if r.Cmp(id) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+var _ NFTContract = (*FakeContract)(nil)
+var _ NFTContract = (*gethContract)(nil)
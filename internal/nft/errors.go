@@ -0,0 +1,34 @@
+package nft
+
+import "fmt"
+
+// inputError 請求內容不正確（數量、CSV、區塊高度等），HTTP 層回 400
+type inputError struct {
+	msg string
+}
+
+func invalidInput(format string, args ...interface{}) error {
+	return &inputError{msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *inputError) Error() string { return e.msg }
+
+// InvalidArgument 供 httpapi 辨識為 400
+func (e *inputError) InvalidArgument() bool { return true }
+
+// notFoundError 查無資源（未鑄造的 token、不屬於本合集的合約），HTTP 層回 404
+type notFoundError struct {
+	msg string
+	err error
+}
+
+func notFound(err error, format string, args ...interface{}) error {
+	return &notFoundError{msg: fmt.Sprintf(format, args...), err: err}
+}
+
+func (e *notFoundError) Error() string { return e.msg }
+
+func (e *notFoundError) Unwrap() error { return e.err }
+
+// NotFound 供 httpapi 辨識為 404
+func (e *notFoundError) NotFound() bool { return true }
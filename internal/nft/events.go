@@ -0,0 +1,146 @@
+package nft
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/wkchen007/nftweb-back/internal/models"
+)
+
+// EventSink 接收合約事件，例如 webhook.Dispatcher.Publish；eventID 在同一條鏈上唯一
+type EventSink func(ctx context.Context, eventType, eventID string, src *models.EventSource, data interface{}) error
+
+type TokenEvent struct {
+	Contract    string `json:"contract"`
+	From        string `json:"from,omitempty"`
+	To          string `json:"to,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Approved    string `json:"approved,omitempty"`
+	TokenID     string `json:"tokenId"`
+	TxHash      string `json:"txHash"`
+	BlockNumber uint64 `json:"blockNumber"`
+	LogIndex    uint   `json:"logIndex"`
+}
+
+// PollEvents 定期讀取 Transfer / Approval log 並交給 sink，直到 ctx 結束。
+// 只處理已有鏈設定 confirmations 個確認的區塊；進度存在 DB，重啟後從上次的區塊繼續（第一次從合約部署區塊開始）；中途失敗的區間會重送，由 sink 依 eventID 去重
+func (s *Service) PollEvents(ctx context.Context, interval time.Duration, sink EventSink) {
+	var from uint64
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		next, err := s.pollOnce(ctx, from, sink)
+		if err != nil {
+			slog.ErrorContext(ctx, "[nft] poll events", "collection", s.Slug(), "err", err)
+		} else {
+			from = next
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollOnce 處理 [from, head - confirmations] 的事件（最多 logRange 個區塊），回傳下一次的起點
+func (s *Service) pollOnce(ctx context.Context, from uint64, sink EventSink) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Poll)
+	defer cancel()
+
+	if from == 0 {
+		start, err := s.eventCursor(ctx)
+		if err != nil {
+			return 0, err
+		}
+		from = start
+	}
+	head, err := s.client.Backend().BlockNumber(ctx)
+	if err != nil {
+		return from, fmt.Errorf("get block number: %w", err)
+	}
+	confirmations := s.client.Chain().Confirmations
+	if head < from+confirmations {
+		return from, nil
+	}
+	head -= confirmations
+	// 一輪最多查 logRange 個區塊，落後較多時分幾輪追上
+	head = min(head, from+logRange-1)
+
+	opts := &bind.FilterOpts{Start: from, End: &head, Context: ctx}
+	transfers, err := s.con.FilterTransfer(opts, nil, nil, nil)
+	if err != nil {
+		return from, fmt.Errorf("filter transfer: %w", err)
+	}
+	approvals, err := s.con.FilterApproval(opts, nil, nil, nil)
+	if err != nil {
+		return from, fmt.Errorf("filter approval: %w", err)
+	}
+
+	contract := s.con.Address().Hex()
+	chainID := s.client.Chain().ID
+	for _, e := range transfers {
+		eventType := models.EventNFTTransferred
+		if e.From == (gethcommon.Address{}) {
+			eventType = models.EventNFTMinted
+		}
+		data := TokenEvent{
+			Contract:    contract,
+			From:        e.From.Hex(),
+			To:          e.To.Hex(),
+			TokenID:     e.TokenID.String(),
+			TxHash:      e.Raw.TxHash.Hex(),
+			BlockNumber: e.Raw.BlockNumber,
+			LogIndex:    e.Raw.Index,
+		}
+		src := &models.EventSource{ChainID: chainID, Block: e.Raw.BlockNumber}
+		if err := sink(ctx, eventType, logEventID(e.Raw), src, data); err != nil {
+			return from, err
+		}
+	}
+	for _, e := range approvals {
+		data := TokenEvent{
+			Contract:    contract,
+			Owner:       e.Owner.Hex(),
+			Approved:    e.Approved.Hex(),
+			TokenID:     e.TokenID.String(),
+			TxHash:      e.Raw.TxHash.Hex(),
+			BlockNumber: e.Raw.BlockNumber,
+			LogIndex:    e.Raw.Index,
+		}
+		src := &models.EventSource{ChainID: chainID, Block: e.Raw.BlockNumber}
+		if err := sink(ctx, models.EventNFTApproval, logEventID(e.Raw), src, data); err != nil {
+			return from, err
+		}
+	}
+
+	if err := s.DB.SaveEventCursor(ctx, chainID, contract, head+1); err != nil {
+		return from, fmt.Errorf("save event cursor: %w", err)
+	}
+	return head + 1, nil
+}
+
+// eventCursor 上次輪詢存下的起點；沒有記錄時從合約部署區塊開始
+func (s *Service) eventCursor(ctx context.Context) (uint64, error) {
+	next, err := s.DB.GetEventCursor(ctx, s.client.Chain().ID, s.con.Address().Hex())
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.deployBlock(ctx)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get event cursor: %w", err)
+	}
+	return next, nil
+}
+
+func logEventID(l types.Log) string {
+	return fmt.Sprintf("%s:%d", l.TxHash.Hex(), l.Index)
+}
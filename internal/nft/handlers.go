@@ -1,22 +1,43 @@
 package nft
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
+	"github.com/wkchen007/nftweb-back/internal/logging"
 	"github.com/wkchen007/nftweb-back/internal/models"
+	"github.com/wkchen007/nftweb-back/internal/worker"
 )
 
 type Handlers struct {
-	svc *Service
+	cur     atomic.Pointer[Service]
+	workers *worker.Group // 背景批次；nil 時不隨關閉服務取消
 }
 
 func NewHandlers(svc *Service) *Handlers {
-	return &Handlers{svc: svc}
+	h := &Handlers{}
+	h.cur.Store(svc)
+	return h
+}
+
+// svc 目前使用中的 Service；reload 後的請求會拿到新的
+func (h *Handlers) svc() *Service {
+	return h.cur.Load()
+}
+
+// Service 目前使用中的 Service
+func (h *Handlers) Service() *Service {
+	return h.svc()
 }
 
 // OwnerOf 查詢 NFT 擁有者
@@ -35,14 +56,14 @@ func (h *Handlers) OwnerOf(w http.ResponseWriter, r *http.Request) {
 	var req OwnerOfRequest
 	err := h.readJSON(w, r, &req)
 	if err != nil {
-		h.errorJSON(w, err, http.StatusBadRequest)
+		httpapi.WriteError(w, r, err)
 		return
 	}
-	log.Printf("[nft] OwnerOf request: %+v", req)
+	slog.InfoContext(r.Context(), "[nft] OwnerOf request", "req", req)
 
-	resp, err := h.svc.OwnerOf(req)
+	resp, err := h.svc().OwnerOf(r.Context(), req)
 	if err != nil {
-		h.errorJSON(w, fmt.Errorf("ownerOf failed: %w", err), http.StatusInternalServerError)
+		httpapi.WriteError(w, r, httpapi.Upstream("ownerOf failed", err))
 		return
 	}
 
@@ -52,7 +73,7 @@ func (h *Handlers) OwnerOf(w http.ResponseWriter, r *http.Request) {
 // Mint 鑄造 NFT
 type MintRequest struct {
 	Amount   string `json:"amount"`
-	ValueETH string `json:"valueETH,omitempty"`
+	ValueETH string `json:"valueETH,omitempty"` // 未填時以合集的 mintPriceWei × amount 計算
 }
 
 type MintResponse struct {
@@ -64,22 +85,14 @@ func (h *Handlers) Mint(w http.ResponseWriter, r *http.Request) {
 	var req MintRequest
 	err := h.readJSON(w, r, &req)
 	if err != nil {
-		h.errorJSON(w, err, http.StatusBadRequest)
+		httpapi.WriteError(w, r, err)
 		return
 	}
-	if req.ValueETH == "" {
-		num, err := strconv.Atoi(req.Amount)
-		if err != nil {
-			h.errorJSON(w, fmt.Errorf("invalid amount: %w", err), http.StatusBadRequest)
-			return
-		}
-		req.ValueETH = fmt.Sprintf("%f", float64(num)*0.01) // 單價 0.01 ETH
-	}
-	log.Printf("[nft] Mint request: %+v", req)
+	slog.InfoContext(r.Context(), "[nft] Mint request", "req", req)
 
-	resp, err := h.svc.Mint(req)
+	resp, err := h.svc().Mint(r.Context(), req)
 	if err != nil {
-		h.errorJSON(w, fmt.Errorf("mint failed: %w", err), http.StatusInternalServerError)
+		httpapi.WriteError(w, r, httpapi.Upstream("mint failed", err))
 		return
 	}
 
@@ -92,9 +105,9 @@ type OwnerResponse struct {
 }
 
 func (h *Handlers) Owner(w http.ResponseWriter, r *http.Request) {
-	resp, err := h.svc.ConCreator()
+	resp, err := h.svc().ConCreator(r.Context())
 	if err != nil {
-		h.errorJSON(w, fmt.Errorf("contract creator failed: %w", err), http.StatusInternalServerError)
+		httpapi.WriteError(w, r, httpapi.Upstream("contract creator failed", err))
 		return
 	}
 
@@ -115,14 +128,14 @@ func (h *Handlers) TokensOfOwner(w http.ResponseWriter, r *http.Request) {
 	var req TokensOfOwnerRequest
 	err := h.readJSON(w, r, &req)
 	if err != nil {
-		h.errorJSON(w, err, http.StatusBadRequest)
+		httpapi.WriteError(w, r, err)
 		return
 	}
-	log.Printf("[nft] TokensOfOwner request: %+v", req)
+	slog.InfoContext(r.Context(), "[nft] TokensOfOwner request", "req", req)
 
-	resp, err := h.svc.TokensOfOwner(req)
+	resp, err := h.svc().TokensOfOwner(r.Context(), req)
 	if err != nil {
-		h.errorJSON(w, fmt.Errorf("tokensOfOwner failed: %w", err), http.StatusInternalServerError)
+		httpapi.WriteError(w, r, httpapi.Upstream("tokensOfOwner failed", err))
 		return
 	}
 
@@ -135,9 +148,9 @@ type ConResponse struct {
 }
 
 func (h *Handlers) OpenBlindBox(w http.ResponseWriter, r *http.Request) {
-	resp, err := h.svc.OpenBlindBox()
+	resp, err := h.svc().OpenBlindBox(r.Context())
 	if err != nil {
-		h.errorJSON(w, fmt.Errorf("openBlindBox failed: %w", err), http.StatusInternalServerError)
+		httpapi.WriteError(w, r, httpapi.Upstream("openBlindBox failed", err))
 		return
 	}
 
@@ -145,9 +158,9 @@ func (h *Handlers) OpenBlindBox(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handlers) Withdraw(w http.ResponseWriter, r *http.Request) {
-	resp, err := h.svc.Withdraw()
+	resp, err := h.svc().Withdraw(r.Context())
 	if err != nil {
-		h.errorJSON(w, fmt.Errorf("withdraw failed: %w", err), http.StatusInternalServerError)
+		httpapi.WriteError(w, r, httpapi.Upstream("withdraw failed", err))
 		return
 	}
 
@@ -157,19 +170,19 @@ func (h *Handlers) Withdraw(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) TokenURI(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		h.errorJSON(w, fmt.Errorf("tokenId is required"), http.StatusBadRequest)
+		httpapi.WriteError(w, r, httpapi.Invalid("tokenId is required"))
 		return
 	}
 
 	bigID := new(big.Int)
 	_, ok := bigID.SetString(id, 10)
 	if !ok {
-		h.errorJSON(w, fmt.Errorf("invalid tokenId: %s", id), http.StatusBadRequest)
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid tokenId: %s", id))
 		return
 	}
-	uri, err := h.svc.TokenURI(bigID)
+	uri, err := h.svc().TokenURI(r.Context(), bigID)
 	if err != nil {
-		h.errorJSON(w, fmt.Errorf("tokenURI failed: %w", err), http.StatusInternalServerError)
+		httpapi.WriteError(w, r, httpapi.Upstream("tokenURI failed", err))
 		return
 	}
 
@@ -191,9 +204,9 @@ type BalanceResponse struct {
 }
 
 func (h *Handlers) Balance(w http.ResponseWriter, r *http.Request) {
-	resp, err := h.svc.Balance()
+	resp, err := h.svc().Balance(r.Context())
 	if err != nil {
-		h.errorJSON(w, fmt.Errorf("balance failed: %w", err), http.StatusInternalServerError)
+		httpapi.WriteError(w, r, httpapi.Upstream("balance failed", err))
 		return
 	}
 
@@ -206,9 +219,314 @@ type CountResponse struct {
 }
 
 func (h *Handlers) Count(w http.ResponseWriter, r *http.Request) {
-	resp, err := h.svc.Count()
+	resp, err := h.svc().Count(r.Context())
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Upstream("counter failed", err))
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handlers) Stats(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc().Stats(r.Context())
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Upstream("stats failed", err))
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+type SnapshotRequest struct {
+	BlockNumber uint64 `json:"blockNumber"` // 0 代表最新區塊
+}
+
+// CreateSnapshot 計算並儲存指定區塊的持有者快照；?format=csv 以 CSV 回傳
+func (h *Handlers) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	var req SnapshotRequest
+	err := h.readJSON(w, r, &req)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "[nft] Snapshot request", "req", req)
+
+	snap, err := h.svc().Snapshot(r.Context(), req.BlockNumber)
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Upstream("snapshot failed", err))
+		return
+	}
+	snap.ID, err = h.svc().DB.InsertHolderSnapshot(r.Context(), snap)
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return
+	}
+
+	h.writeSnapshot(w, r, http.StatusCreated, snap)
+}
+
+func (h *Handlers) Snapshots(w http.ResponseWriter, r *http.Request) {
+	// 只列出本合集合約的快照
+	snaps, err := h.svc().DB.AllHolderSnapshots(r.Context(), h.svc().Contract().Hex())
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return
+	}
+	if snaps == nil {
+		snaps = []*models.HolderSnapshot{}
+	}
+
+	h.writeJSON(w, http.StatusOK, snaps)
+}
+
+func (h *Handlers) GetSnapshot(w http.ResponseWriter, r *http.Request) {
+	snap, ok := h.loadSnapshot(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	h.writeSnapshot(w, r, http.StatusOK, *snap)
+}
+
+func (h *Handlers) DiffSnapshot(w http.ResponseWriter, r *http.Request) {
+	from, ok := h.loadSnapshot(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	to, ok := h.loadSnapshot(w, r, chi.URLParam(r, "other"))
+	if !ok {
+		return
+	}
+	if from.Contract != to.Contract {
+		httpapi.WriteError(w, r, httpapi.Invalid("snapshots belong to different contracts"))
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, DiffSnapshots(*from, *to))
+}
+
+func (h *Handlers) loadSnapshot(w http.ResponseWriter, r *http.Request, param string) (*models.HolderSnapshot, bool) {
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid snapshot id: %s", param))
+		return nil, false
+	}
+	snap, err := h.svc().DB.GetHolderSnapshot(r.Context(), id)
+	if err == nil && !strings.EqualFold(snap.Contract, h.svc().Contract().Hex()) {
+		err = sql.ErrNoRows // 其他合集的快照
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.WriteError(w, r, httpapi.NotFound("snapshot %d not found", id))
+		return nil, false
+	}
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return nil, false
+	}
+	return snap, true
+}
+
+func (h *Handlers) writeSnapshot(w http.ResponseWriter, r *http.Request, status int, snap models.HolderSnapshot) {
+	if r.URL.Query().Get("format") != "csv" {
+		h.writeJSON(w, status, snap)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=snapshot-%d.csv", snap.BlockNumber))
+	w.WriteHeader(status)
+	if err := WriteSnapshotCSV(w, snap); err != nil {
+		slog.ErrorContext(r.Context(), "[nft] write snapshot csv", "err", err)
+	}
+}
+
+// CreateAirdrop 以 CSV（address,amount）建立空投批次並在背景送出；?dryRun=true 只做驗證
+func (h *Handlers) CreateAirdrop(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1024*1024)
+	job, err := h.svc().PrepareAirdrop(r.Context(), r.Body)
+	if err != nil {
+		// 驗證失敗時連同每列結果一起回傳
+		var invalid *httpapi.Error
+		if errors.As(err, &invalid) && len(job.Rows) > 0 {
+			err = invalid.WithDetails(job.Rows)
+		}
+		httpapi.WriteError(w, r, httpapi.Upstream("prepare airdrop failed", err))
+		return
+	}
+	if r.URL.Query().Get("dryRun") == "true" {
+		h.writeJSON(w, http.StatusOK, job)
+		return
+	}
+
+	job.ID, err = h.svc().DB.InsertAirdrop(r.Context(), job)
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return
+	}
+	slog.InfoContext(r.Context(), "[nft] airdrop created", "id", job.ID, "tokens", job.TotalAmount, "rows", len(job.Rows), "value_eth", job.ValueETH)
+	if err := h.goAirdrop(r, job.ID); err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusAccepted, job)
+}
+
+func (h *Handlers) Airdrops(w http.ResponseWriter, r *http.Request) {
+	// 只列出本合集合約的空投
+	jobs, err := h.svc().DB.AllAirdrops(r.Context(), h.svc().Contract().Hex())
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return
+	}
+	if jobs == nil {
+		jobs = []*models.Airdrop{}
+	}
+
+	h.writeJSON(w, http.StatusOK, jobs)
+}
+
+// GetAirdrop 回傳批次與每列的 tx hash / 失敗原因
+func (h *Handlers) GetAirdrop(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.loadAirdrop(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, job)
+}
+
+// ResumeAirdrop 重新送出 pending / failed 的列。
+// DB 的 running 狀態在服務中斷後會殘留，是否執行中以 advisory lock 為準（持有的連線中斷時自動釋放）
+func (h *Handlers) ResumeAirdrop(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.loadAirdrop(w, r, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	if err := h.goAirdrop(r, job.ID); err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusAccepted, JSONResponse{Message: fmt.Sprintf("airdrop %d resumed", job.ID)})
+}
+
+// goAirdrop 取得執行權後在背景執行批次；批次比 request 長，因此不隨 request 取消，
+// 但關閉服務時會在送完目前這筆交易後停下，之後可用 resume 續傳。已在其他地方執行時回傳 409
+func (h *Handlers) goAirdrop(r *http.Request, id int) error {
+	svc := h.svc()
+	release, err := svc.ClaimAirdrop(r.Context(), id)
+	if errors.Is(err, ErrAirdropRunning) {
+		return httpapi.Conflict("%s", err)
+	}
+	if err != nil {
+		return httpapi.Internal(err)
+	}
+
+	reqID := logging.RequestID(r.Context())
+	run := func(ctx context.Context) {
+		defer release()
+		// 背景批次的 log 仍帶上建立它的 request id
+		ctx = logging.WithRequestID(ctx, reqID)
+		if err := svc.RunAirdrop(ctx, id); err != nil {
+			slog.ErrorContext(ctx, "[nft] airdrop failed", "id", id, "err", err)
+		}
+	}
+	if h.workers == nil {
+		go run(context.WithoutCancel(r.Context()))
+		return nil
+	}
+	h.workers.Go(run)
+	return nil
+}
+
+func (h *Handlers) loadAirdrop(w http.ResponseWriter, r *http.Request, param string) (*models.Airdrop, bool) {
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid airdrop id: %s", param))
+		return nil, false
+	}
+	job, err := h.svc().DB.GetAirdrop(r.Context(), id)
+	if err == nil && !strings.EqualFold(job.Contract, h.svc().Contract().Hex()) {
+		err = sql.ErrNoRows // 其他合集的空投
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.WriteError(w, r, httpapi.NotFound("airdrop %d not found", id))
+		return nil, false
+	}
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return nil, false
+	}
+	return job, true
+}
+
+// Assignment 公開目前的 commitment；開盒後連同 seed 與 mapping 供任何人驗證
+func (h *Handlers) Assignment(w http.ResponseWriter, r *http.Request) {
+	a, err := h.svc().Assignment(r.Context())
+	if err != nil {
+		h.assignmentError(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, a)
+}
+
+// CommitAssignment 開賣前抽出分配並寫入 commitment（只能做一次）
+func (h *Handlers) CommitAssignment(w http.ResponseWriter, r *http.Request) {
+	a, err := h.svc().CommitAssignment(r.Context())
+	if err != nil {
+		h.assignmentError(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, a)
+}
+
+// RevealAssignment 公開 seed 與 mapping
+func (h *Handlers) RevealAssignment(w http.ResponseWriter, r *http.Request) {
+	a, err := h.svc().RevealAssignment(r.Context())
+	if err != nil {
+		h.assignmentError(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, a)
+}
+
+// TokenMetadata 回傳 tokenId 經分配後的 meta / image
+func (h *Handlers) TokenMetadata(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 0 {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid tokenId: %s", chi.URLParam(r, "id")))
+		return
+	}
+
+	item, err := h.svc().TokenMetadata(r.Context(), id)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, item)
+}
+
+func (h *Handlers) assignmentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrNoAssignment):
+		httpapi.WriteError(w, r, httpapi.NotFound("%s", err))
+	case errors.Is(err, ErrAssignmentExists), errors.Is(err, ErrSaleStarted), errors.Is(err, ErrNotOpened):
+		httpapi.WriteError(w, r, httpapi.Conflict("%s", err))
+	default:
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+	}
+}
+
+// Odds 公開目前每個商品的剩餘份數與下一抽機率
+func (h *Handlers) Odds(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc().Odds(r.Context())
 	if err != nil {
-		h.errorJSON(w, fmt.Errorf("counter failed: %w", err), http.StatusInternalServerError)
+		httpapi.WriteError(w, r, httpapi.Upstream("odds failed", err))
 		return
 	}
 
@@ -0,0 +1,125 @@
+package nft
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/wkchen007/nftweb-back/internal/models"
+)
+
+type ItemOdds struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Rarity      string  `json:"rarity"`
+	Weight      int     `json:"weight"`
+	Supply      int     `json:"supply"`
+	Remaining   *int    `json:"remaining,omitempty"` // 開盒後才公開
+	Probability float64 `json:"probability"`         // 下一抽抽中的機率（0~1）
+}
+
+type OddsResponse struct {
+	Contract  string             `json:"contract"`
+	MaxSupply int                `json:"maxSupply"`
+	Minted    int                `json:"minted"`
+	Revealed  bool               `json:"revealed"` // false 時機率只依份數與權重計算
+	Items     []ItemOdds         `json:"items"`
+	Rarity    map[string]float64 `json:"rarity"` // 各稀有度的機率合計
+}
+
+// Odds 依剩餘份數與權重計算下一抽的機率：P(i) = weight_i * remaining_i / Σ weight * remaining。
+// 開盒前分配仍是秘密，只以商品的份數與權重計算（remaining 不公開）；
+// 開盒且分配公開後，已鑄造的 token 才依 mapping（沒有分配時 tokenId = 商品 id）從剩餘份數扣除
+func (s *Service) Odds(ctx context.Context) (OddsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
+	defer cancel()
+	opts := &bind.CallOpts{Context: ctx}
+
+	minted, err := s.con.Counter(opts)
+	if err != nil {
+		return OddsResponse{}, fmt.Errorf("get counter: %w", err)
+	}
+	maxSupply, err := s.con.MaxSupply(opts)
+	if err != nil {
+		return OddsResponse{}, fmt.Errorf("get maxSupply: %w", err)
+	}
+
+	items, err := s.DB.CatalogItems(ctx, s.col.Catalog)
+	if err != nil {
+		return OddsResponse{}, err
+	}
+	drawn, revealed, err := s.drawnItems(ctx, int(minted.Int64()))
+	if err != nil {
+		return OddsResponse{}, err
+	}
+
+	resp := OddsResponse{
+		Contract:  s.con.Address().Hex(),
+		MaxSupply: int(maxSupply.Int64()),
+		Minted:    int(minted.Int64()),
+		Revealed:  revealed,
+		Items:     []ItemOdds{},
+		Rarity:    map[string]float64{},
+	}
+	total := 0
+	for _, item := range items {
+		if !item.Demo {
+			continue
+		}
+		odds := ItemOdds{
+			ID:     item.ID,
+			Name:   item.Name,
+			Rarity: item.Rarity,
+			Weight: item.Weight,
+			Supply: item.Supply,
+		}
+		left := item.Supply
+		if revealed {
+			left = max(item.Supply-drawn[item.ID], 0)
+			odds.Remaining = &left
+		}
+		total += odds.Weight * left
+		resp.Items = append(resp.Items, odds)
+	}
+	if total == 0 || resp.Minted >= resp.MaxSupply {
+		return resp, nil
+	}
+	for i, item := range resp.Items {
+		left := item.Supply
+		if item.Remaining != nil {
+			left = *item.Remaining
+		}
+		p := float64(item.Weight*left) / float64(total)
+		resp.Items[i].Probability = p
+		resp.Rarity[item.Rarity] += p
+	}
+	return resp, nil
+}
+
+// drawnItems 已鑄造 token 對應到各商品的數量；合約未開盒或分配尚未公開時回傳 revealed = false 且不讀 mapping
+func (s *Service) drawnItems(ctx context.Context, minted int) (map[int]int, bool, error) {
+	opened, err := s.isOpened(ctx)
+	if err != nil || !opened {
+		return nil, false, err
+	}
+	a, err := s.assignment(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if a != nil && a.Status != models.AssignmentRevealed {
+		return nil, false, nil
+	}
+
+	drawn := map[int]int{}
+	for id := 0; id < minted; id++ {
+		catalogID := id
+		if a != nil {
+			if id >= len(a.Mapping) {
+				break
+			}
+			catalogID = a.Mapping[id]
+		}
+		drawn[catalogID]++
+	}
+	return drawn, true, nil
+}
@@ -0,0 +1,200 @@
+package nft
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/wkchen007/nftweb-back/internal/ethcli"
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
+	"github.com/wkchen007/nftweb-back/internal/repository"
+	"github.com/wkchen007/nftweb-back/internal/worker"
+)
+
+// Registry 所有合集的 Handlers，以 slug 或合約地址查詢
+type Registry struct {
+	mu     sync.RWMutex
+	chains *ethcli.Chains
+	txs    map[uint64]*txState // 每條鏈的空投執行狀態
+	slugs  []string            // 設定檔順序，第一個為預設合集
+	bySlug map[string]*Handlers
+	byAddr map[gethcommon.Address]*Handlers
+}
+
+// NewRegistry 依設定在各自的鏈上建立每個合集的 Service；所有合集共用同一個 signer，
+// 因此同一條鏈上的合集共用送交易狀態
+func NewRegistry(chains *ethcli.Chains, cfg *Config, db repository.DatabaseRepo, timeouts Timeouts) (*Registry, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("nil config")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		chains: chains,
+		txs:    map[uint64]*txState{},
+		bySlug: map[string]*Handlers{},
+		byAddr: map[gethcommon.Address]*Handlers{},
+	}
+	for _, col := range cfg.All() {
+		client, err := chains.Get(col.Chain)
+		if err != nil {
+			return nil, fmt.Errorf("collection %s: %w", col.Slug, err)
+		}
+		svc, err := NewCollectionService(client, col)
+		if err != nil {
+			return nil, fmt.Errorf("collection %s: %w", col.Slug, err)
+		}
+		svc.DB = db
+		svc.tx = r.txState(client)
+		svc.SetTimeouts(timeouts)
+
+		h := NewHandlers(svc)
+		r.slugs = append(r.slugs, col.Slug)
+		r.bySlug[col.Slug] = h
+		r.byAddr[svc.Contract()] = h
+	}
+	return r, nil
+}
+
+// SetWorkers 空投等背景批次改由 g 管理，關閉服務時一併取消並等待
+func (r *Registry) SetWorkers(g *worker.Group) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, h := range r.bySlug {
+		h.workers = g
+	}
+}
+
+// txState 取得 client 所在鏈的空投執行狀態（呼叫端需持有 mu 或在建構中）
+func (r *Registry) txState(client *ethcli.Client) *txState {
+	id := client.Chain().ID
+	tx, ok := r.txs[id]
+	if !ok {
+		tx = &txState{airdrops: map[int]bool{}}
+		r.txs[id] = tx
+	}
+	return tx
+}
+
+// Default 預設合集（/nft 路由）
+func (r *Registry) Default() *Handlers {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.bySlug[r.slugs[0]]
+}
+
+// Get 以 slug 或 0x 合約地址查詢合集
+func (r *Registry) Get(key string) (*Handlers, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if strings.HasPrefix(key, "0x") && gethcommon.IsHexAddress(key) {
+		h, ok := r.byAddr[gethcommon.HexToAddress(key)]
+		return h, ok
+	}
+	h, ok := r.bySlug[key]
+	return h, ok
+}
+
+// All 依設定檔順序回傳所有合集
+func (r *Registry) All() []*Handlers {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make([]*Handlers, 0, len(r.slugs))
+	for _, slug := range r.slugs {
+		all = append(all, r.bySlug[slug])
+	}
+	return all
+}
+
+// Reload 以新設定重建所有合集的 Service，全部成功才一起替換；
+// 新增或移除合集需要重啟，這裡只記錄 log
+func (r *Registry) Reload(cfg *Config) (diff []string, changed []*Service, err error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type swap struct {
+		h         *Handlers
+		old, next *Service
+	}
+	swaps := []swap{}
+	seen := map[string]bool{}
+	for _, col := range cfg.All() {
+		seen[col.Slug] = true
+		h, ok := r.bySlug[col.Slug]
+		if !ok {
+			slog.Warn("[nft] collection added in config, restart to enable", "collection", col.Slug)
+			continue
+		}
+		old := h.svc()
+		client, err := r.chains.Get(col.Chain)
+		if err != nil {
+			return nil, nil, fmt.Errorf("collection %s: %w", col.Slug, err)
+		}
+		next, err := old.Reload(client, col)
+		if err != nil {
+			return nil, nil, fmt.Errorf("collection %s: %w", col.Slug, err)
+		}
+		next.tx = r.txState(client)
+		swaps = append(swaps, swap{h: h, old: old, next: next})
+	}
+	for _, slug := range r.slugs {
+		if !seen[slug] {
+			slog.Warn("[nft] collection removed from config, restart to disable", "collection", slug)
+		}
+	}
+
+	diff = []string{}
+	r.byAddr = map[gethcommon.Address]*Handlers{}
+	for _, sw := range swaps {
+		sw.h.cur.Store(sw.next)
+		r.byAddr[sw.next.Contract()] = sw.h
+		diff = append(diff, ConfigDiff(sw.old.Config(), sw.next.Config())...)
+		changed = append(changed, sw.next)
+	}
+	for slug, h := range r.bySlug {
+		if !seen[slug] {
+			r.byAddr[h.svc().Contract()] = h
+		}
+	}
+	return diff, changed, nil
+}
+
+// OwnerOf 依 request 的 contract 欄位找到對應合集後查詢擁有者
+func (r *Registry) OwnerOf(w http.ResponseWriter, req *http.Request) {
+	def := r.Default()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, 1024*1024))
+	if err != nil {
+		httpapi.WriteError(w, req, err)
+		return
+	}
+	var payload OwnerOfRequest
+	if err := json.Unmarshal(body, &payload); err != nil {
+		httpapi.WriteError(w, req, httpapi.New(http.StatusBadRequest, httpapi.CodeInvalidJSON, "invalid json: %s", err))
+		return
+	}
+
+	h := def
+	if payload.Contract != "" {
+		var ok bool
+		h, ok = r.Get(payload.Contract)
+		if !ok {
+			httpapi.WriteError(w, req, httpapi.NotFound("unsupported contract address"))
+			return
+		}
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	h.OwnerOf(w, req)
+}
@@ -0,0 +1,55 @@
+package nft
+
+import (
+	"fmt"
+
+	"github.com/wkchen007/nftweb-back/internal/ethcli"
+)
+
+// Reload 以新設定與 client（合集所在的鏈）建立 Service（重新讀取 ABI 與合約），沿用 DB 與送交易狀態；
+// 回傳的 Service 尚未生效，需由呼叫端替換
+func (s *Service) Reload(client *ethcli.Client, col CollectionConfig) (*Service, error) {
+	col = col.withDefaults()
+	if col.Slug != s.col.Slug {
+		return nil, fmt.Errorf("slug cannot change on reload: %s -> %s", s.col.Slug, col.Slug)
+	}
+	if err := col.Validate(); err != nil {
+		return nil, err
+	}
+	next, err := NewCollectionService(client, col)
+	if err != nil {
+		return nil, err
+	}
+	next.DB = s.DB
+	next.tx = s.tx
+	next.timeouts = s.timeouts
+	// 合約沒變時沿用已查到的部署區塊
+	if next.client == s.client && next.con.Address() == s.con.Address() && next.conTxHash == s.conTxHash {
+		s.mu.Lock()
+		next.conBlock = s.conBlock
+		s.mu.Unlock()
+	}
+	return next, nil
+}
+
+// Config 回傳 Service 使用中的合集設定
+func (s *Service) Config() CollectionConfig {
+	return s.col
+}
+
+// ConfigDiff 列出兩份合集設定不同的欄位，格式為 "slug.欄位: 舊值 -> 新值"
+func ConfigDiff(old, new CollectionConfig) []string {
+	diff := []string{}
+	add := func(field string, a, b interface{}) {
+		if a != b {
+			diff = append(diff, fmt.Sprintf("%s.%s: %v -> %v", old.Slug, field, a, b))
+		}
+	}
+	add("chain", old.Chain, new.Chain)
+	add("contractAddress", old.ContractAddress, new.ContractAddress)
+	add("contractTxHash", old.ContractTxHash, new.ContractTxHash)
+	add("abiPath", old.ABIPath, new.ABIPath)
+	add("maxScanTokenID", old.MaxScanTokenID, new.MaxScanTokenID)
+	add("catalog", old.Catalog, new.Catalog)
+	return diff
+}
@@ -3,11 +3,12 @@ package nft
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -16,17 +17,52 @@ import (
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/wkchen007/nftweb-back/internal/ethcli"
+	"github.com/wkchen007/nftweb-back/internal/idempotency"
+	"github.com/wkchen007/nftweb-back/internal/metrics"
 	"github.com/wkchen007/nftweb-back/internal/models"
 	"github.com/wkchen007/nftweb-back/internal/repository"
+	"github.com/wkchen007/nftweb-back/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// ChainClient Service 使用的鏈連線與 signer；正式環境為 *ethcli.Client，測試可注入假的實作
+type ChainClient interface {
+	Chain() ethcli.Chain
+	ChainID() *big.Int
+	Network() string
+	Backend() ethcli.Backend
+	From() gethcommon.Address
+	NewTransactor(ctx context.Context) (*bind.TransactOpts, error)
+	LockTx()
+	UnlockTx()
+	WaitMined(ctx context.Context, txHash string) (*types.Receipt, error)
+}
+
+var _ ChainClient = (*ethcli.Client)(nil)
+
+// draftGasLimit 組出交易 calldata 時的暫定 gasLimit，實際值由 EstimateGas 決定
+const draftGasLimit = 1
+
 type Service struct {
-	client    *ethcli.Client
-	abi       abi.ABI
-	contract  gethcommon.Address
+	client    ChainClient
+	con       NFTContract
 	conTxHash gethcommon.Hash
-	config    *Config
+	col       CollectionConfig
+	price     *big.Int // 合集設定的 mint 單價
 	DB        repository.DatabaseRepo
+
+	mu         sync.Mutex
+	conBlock   uint64               // 合約部署區塊（lazy 取得）
+	blockTimes map[uint64]time.Time // 鑄造區塊的時間，/stats 每次只查新的區塊
+
+	tx       *txState // reload 後沿用，避免新舊 Service 同時執行同一個空投
+	timeouts Timeouts
+}
+
+// txState 同一條鏈的空投執行狀態，跨 reload 共用（送交易的鎖在 ethcli.Client）
+type txState struct {
+	jobsMu   sync.Mutex
+	airdrops map[int]bool // 執行中的空投批次
 }
 
 func loadABIFromFile(path string) (abi.ABI, error) {
@@ -37,15 +73,21 @@ func loadABIFromFile(path string) (abi.ABI, error) {
 	return abi.JSON(strings.NewReader(string(b)))
 }
 
+// NewServiceFromConfig 建立預設合集（config 的 nft 區塊）的 Service
 func NewServiceFromConfig(client *ethcli.Client, cfg *Config) (*Service, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("nil config")
 	}
-	return NewServiceWithABIPath(client, cfg.NFT.ABIPath, cfg.NFT.ContractAddress, cfg.NFT.ContractTxHash, cfg)
+	return NewCollectionService(client, cfg.All()[0])
+}
+
+// NewCollectionService 依合集設定建立 Service
+func NewCollectionService(client *ethcli.Client, col CollectionConfig) (*Service, error) {
+	return NewServiceWithABIPath(client, col.ABIPath, col.ContractAddress, col.ContractTxHash, col)
 }
 
 // NewServiceWithABIPath：顯式指定 ABI 路徑與合約地址（方便測試）
-func NewServiceWithABIPath(client *ethcli.Client, abiPath, contractAddr string, conTxHash string, cfg *Config) (*Service, error) {
+func NewServiceWithABIPath(client *ethcli.Client, abiPath, contractAddr string, conTxHash string, col CollectionConfig) (*Service, error) {
 	if strings.TrimSpace(abiPath) == "" {
 		return nil, fmt.Errorf("abiPath is empty")
 	}
@@ -59,17 +101,55 @@ func NewServiceWithABIPath(client *ethcli.Client, abiPath, contractAddr string,
 	if err != nil {
 		return nil, err
 	}
+	con, err := NewGethContract(gethcommon.HexToAddress(contractAddr), parsed, client.ConBackend())
+	if err != nil {
+		return nil, err
+	}
+	return NewServiceWithContract(client, con, conTxHash, col)
+}
+
+// NewServiceWithContract：直接注入 NFTContract（例如 FakeContract）與 ChainClient，不需 RPC 即可測試 Service
+func NewServiceWithContract(client ChainClient, con NFTContract, conTxHash string, col CollectionConfig) (*Service, error) {
+	if con == nil {
+		return nil, fmt.Errorf("nil contract")
+	}
+	if col.Slug == "" {
+		col.Slug = DefaultSlug
+	}
+	col = col.withDefaults()
+	price, err := col.mintPrice()
+	if err != nil {
+		return nil, err
+	}
 	return &Service{
-		client:    client,
-		abi:       parsed,
-		contract:  gethcommon.HexToAddress(contractAddr),
-		conTxHash: gethcommon.HexToHash(conTxHash),
-		config:    cfg,
+		client:     client,
+		con:        con,
+		conTxHash:  gethcommon.HexToHash(conTxHash),
+		col:        col,
+		price:      price,
+		blockTimes: map[uint64]time.Time{},
+		tx:         &txState{airdrops: map[int]bool{}},
+		timeouts:   DefaultTimeouts(),
 	}, nil
 }
 
-func (s *Service) ConCreator() (OwnerResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// Slug 合集 slug
+func (s *Service) Slug() string {
+	return s.col.Slug
+}
+
+// Contract 合集的合約地址
+func (s *Service) Contract() gethcommon.Address {
+	return s.con.Address()
+}
+
+// Chain 合集所在的鏈
+func (s *Service) Chain() ethcli.Chain {
+	return s.client.Chain()
+}
+
+func (s *Service) ConCreator(ctx context.Context) (OwnerResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
 	defer cancel()
 	//查看交易內容
 	backend := s.client.Backend()
@@ -86,37 +166,29 @@ func (s *Service) ConCreator() (OwnerResponse, error) {
 	}
 
 	return OwnerResponse{
-		Contract: s.contract.Hex(),
+		Contract: s.con.Address().Hex(),
 		Owner:    creator.Hex(),
 	}, nil
 }
 
-func (s *Service) OwnerOf(req OwnerOfRequest) (OwnerOfResponse, error) {
+func (s *Service) OwnerOf(ctx context.Context, req OwnerOfRequest) (OwnerOfResponse, error) {
 	contract := gethcommon.HexToAddress(req.Contract)
-	if contract != s.contract {
-		return OwnerOfResponse{}, fmt.Errorf("unsupported contract address")
+	if contract != s.con.Address() {
+		return OwnerOfResponse{}, notFound(nil, "unsupported contract address")
 	}
 
 	tokenId, ok := new(big.Int).SetString(req.TokenID, 10)
 	if !ok {
-		return OwnerOfResponse{}, fmt.Errorf("invalid tokenId")
+		return OwnerOfResponse{}, invalidInput("invalid tokenId")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
 	defer cancel()
 
-	bound := bind.NewBoundContract(s.contract, s.abi, s.client.ConBackend(), nil, nil)
-	var out []interface{}
-	if err := bound.Call(&bind.CallOpts{Context: ctx}, &out, "ownerOf", tokenId); err != nil {
+	addr, err := s.con.OwnerOf(&bind.CallOpts{Context: ctx}, tokenId)
+	if err != nil {
 		return OwnerOfResponse{}, err
 	}
-	if len(out) == 0 {
-		return OwnerOfResponse{}, fmt.Errorf("no result")
-	}
-	addr, ok := out[0].(gethcommon.Address)
-	if !ok {
-		return OwnerOfResponse{}, fmt.Errorf("invalid return type")
-	}
 	return OwnerOfResponse{
 		Contract: req.Contract,
 		TokenID:  req.TokenID,
@@ -124,77 +196,120 @@ func (s *Service) OwnerOf(req OwnerOfRequest) (OwnerOfResponse, error) {
 	}, nil
 }
 
-// 打包、估算、簽名並送出 EIP-1559 交易（使用 newTransactor 設好的 tip/feecap）
-func (s *Service) sendTx(ctx context.Context, method string, value *big.Int, args ...interface{}) (txHash *gethcommon.Hash, err error) {
-	// calldata
+// 估算、簽名並送出 EIP-1559 交易（使用 newTransactor 設好的 tip/feecap）
+func (s *Service) sendTx(ctx context.Context, method string, value *big.Int, transact func(opts *bind.TransactOpts) (*types.Transaction, error)) (txHash *gethcommon.Hash, err error) {
+	return s.sendTxWith(ctx, method, value, nil, transact)
+}
+
+// sendTxWith 同 sendTx；signed 不為 nil 時在交易簽好、送出之前呼叫（例如先把 tx hash 記進 DB），回傳錯誤則不送出。
+// 送出失敗時仍回傳 tx hash：節點可能其實已收到，呼叫端應先以 hash 確認再決定是否重送。
+// 從取 nonce 到送出期間持有 client 的送交易鎖，同一條鏈的其他交易（含 TransferETH）不會搶同一個 nonce
+func (s *Service) sendTxWith(ctx context.Context, method string, value *big.Int, signed func(tx *types.Transaction) error, transact func(opts *bind.TransactOpts) (*types.Transaction, error)) (txHash *gethcommon.Hash, err error) {
+	s.client.LockTx()
+	defer s.client.UnlockTx()
+
+	// 子 span 為 nonce、gas 估算與送出等 RPC
+	ctx, span := tracing.Start(ctx, "nft."+method)
+	span.SetAttributes(
+		attribute.String("nft.collection", s.Slug()),
+		attribute.String("nft.contract", s.con.Address().Hex()),
+		attribute.String("eth.chain", s.client.Network()),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	if value == nil {
 		value = big.NewInt(0)
 	}
-	data, err := s.abi.Pack(method, args...)
-	if err != nil {
-		return nil, err
-	}
 
 	// opts（已含 Nonce / GasTipCap / GasFeeCap）
-	backend := s.client.ConBackend()
 	opts, err := s.client.NewTransactor(ctx)
 	if err != nil {
 		return nil, err
 	}
 	opts.Value = new(big.Int).Set(value)
 
-	// 估 gasLimit，使用 EIP-1559 欄位
-	call := ethereum.CallMsg{From: opts.From, To: &s.contract, Data: data, Value: value, GasFeeCap: opts.GasFeeCap, GasTipCap: opts.GasTipCap}
-	gasLimit, gasErr := backend.EstimateGas(ctx, call)
-	if gasErr != nil {
-		return nil, fmt.Errorf("estimate gas: %w", gasErr)
+	// 先組出未簽名的交易取得 calldata：GasLimit 不為 0 時 bind 不會自行估算
+	draftOpts := *opts
+	draftOpts.GasLimit = draftGasLimit
+	draftOpts.NoSend = true
+	draftOpts.Signer = func(_ gethcommon.Address, tx *types.Transaction) (*types.Transaction, error) { return tx, nil }
+	draft, err := transact(&draftOpts)
+	if err != nil {
+		metrics.TxSent.WithLabelValues(s.client.Network(), s.con.Address().Hex(), method, metrics.Result(err)).Inc()
+		return nil, err
 	}
-	opts.GasLimit = gasLimit
-
-	bound := bind.NewBoundContract(s.contract, s.abi, backend, backend, backend)
 
-	tx, err := bound.Transact(opts, method, args...)
+	// 估 gasLimit，使用 EIP-1559 欄位
+	call := ethereum.CallMsg{From: opts.From, To: draft.To(), Data: draft.Data(), Value: value, GasFeeCap: opts.GasFeeCap, GasTipCap: opts.GasTipCap}
+	gasLimit, err := s.client.Backend().EstimateGas(ctx, call)
 	if err != nil {
-		return nil, err
+		metrics.TxSent.WithLabelValues(s.client.Network(), s.con.Address().Hex(), method, metrics.Result(err)).Inc()
+		return nil, fmt.Errorf("estimate gas: %w", err)
+	}
+
+	// 只簽名，送出由下面自己做，才能先拿到 tx hash
+	tx, err := opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{
+		Nonce:     opts.Nonce.Uint64(),
+		GasTipCap: opts.GasTipCap,
+		GasFeeCap: opts.GasFeeCap,
+		Gas:       gasLimit,
+		To:        draft.To(),
+		Value:     value,
+		Data:      draft.Data(),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("sign tx: %w", err)
+	}
+	hash := tx.Hash()
+	span.SetAttributes(attribute.String("eth.tx_hash", hash.Hex()))
+	if signed != nil {
+		if err := signed(tx); err != nil {
+			return nil, err
+		}
 	}
 
-	log.Printf("[nft] %s tx sent: %s contract: %s from: %s", method, tx.Hash().Hex(), s.contract.Hex(), opts.From.Hex())
+	idempotency.MarkSent(ctx)
+	err = s.client.Backend().SendTransaction(ctx, tx)
+	metrics.TxSent.WithLabelValues(s.client.Network(), s.con.Address().Hex(), method, metrics.Result(err)).Inc()
+	if err != nil {
+		return &hash, fmt.Errorf("send tx %s: %w", hash.Hex(), err)
+	}
 
-	hash := tx.Hash()
+	slog.InfoContext(ctx, "[nft] tx sent", "method", method, "tx", hash.Hex(), "nonce", tx.Nonce(), "contract", s.con.Address().Hex(), "from", opts.From.Hex())
 	return &hash, nil
 }
 
-func (s *Service) OpenBlindBox() (ConResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Service) OpenBlindBox(ctx context.Context) (ConResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Tx)
 	defer cancel()
 
-	hash, err := s.sendTx(ctx, "openBlindBox", nil)
+	hash, err := s.sendTx(ctx, "openBlindBox", nil, s.con.OpenBlindBox)
 	if err != nil {
 		return ConResponse{}, fmt.Errorf("openBlindBox failed: %w", err)
 	}
 
 	return ConResponse{
 		TxHash:   hash.Hex(),
-		Contract: s.contract.Hex(),
+		Contract: s.con.Address().Hex(),
 	}, nil
 }
 
-func (s *Service) Withdraw() (ConResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Service) Withdraw(ctx context.Context) (ConResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Tx)
 	defer cancel()
 
-	hash, err := s.sendTx(ctx, "withdraw", nil)
+	hash, err := s.sendTx(ctx, "withdraw", nil, s.con.Withdraw)
 	if err != nil {
 		return ConResponse{}, fmt.Errorf("withdraw failed: %w", err)
 	}
 
 	return ConResponse{
 		TxHash:   hash.Hex(),
-		Contract: s.contract.Hex(),
+		Contract: s.con.Address().Hex(),
 	}, nil
 }
 
-func (s *Service) Mint(req MintRequest) (MintResponse, error) {
+func (s *Service) Mint(ctx context.Context, req MintRequest) (MintResponse, error) {
 	ok := ethcli.IsHexAddress(s.client.From().Hex())
 	if !ok {
 		return MintResponse{}, fmt.Errorf("invalid to address")
@@ -202,18 +317,25 @@ func (s *Service) Mint(req MintRequest) (MintResponse, error) {
 	to := ethcli.GethHexToAddress(s.client.From().Hex())
 	amount, ok := new(big.Int).SetString(req.Amount, 10)
 	if !ok {
-		return MintResponse{}, fmt.Errorf("invalid amount")
+		return MintResponse{}, invalidInput("invalid amount")
 	}
 
-	valueWei, err := ethcli.AmountToWei(req.ValueETH)
-	if err != nil {
-		return MintResponse{}, fmt.Errorf("invalid valueEth: %w", err)
+	// 未指定金額時以合集單價（wei）計算，不經過浮點數
+	valueWei := new(big.Int).Mul(s.price, amount)
+	if req.ValueETH != "" {
+		var err error
+		valueWei, err = ethcli.AmountToWei(req.ValueETH)
+		if err != nil {
+			return MintResponse{}, invalidInput("invalid valueEth: %s", err)
+		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Tx)
 	defer cancel()
 
-	tx, err := s.sendTx(ctx, "mint", valueWei, to, amount)
+	tx, err := s.sendTx(ctx, "mint", valueWei, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return s.con.Mint(opts, to, amount)
+	})
 	if err != nil {
 		return MintResponse{}, fmt.Errorf("mint failed: %w", err)
 	}
@@ -225,84 +347,69 @@ func (s *Service) Mint(req MintRequest) (MintResponse, error) {
 }
 
 // Counter 讀取合約的 counter()，通常代表已鑄出的數量上限（或已經 mint 的總數）
-func (s *Service) Counter() (*big.Int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Service) Counter(ctx context.Context) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
 	defer cancel()
 
-	backend := s.client.ConBackend()
-	bound := bind.NewBoundContract(s.contract, s.abi, backend, nil, nil)
-
-	var out []interface{}
-	if err := bound.Call(&bind.CallOpts{Context: ctx}, &out, "counter"); err != nil {
+	count, err := s.con.Counter(&bind.CallOpts{Context: ctx})
+	if err != nil {
 		return nil, fmt.Errorf("counter call failed: %w", err)
 	}
-	if len(out) == 0 {
-		return nil, fmt.Errorf("counter returned no result")
-	}
-
-	switch v := out[0].(type) {
-	case *big.Int:
-		return v, nil
-	case big.Int:
-		return new(big.Int).Set(&v), nil
-	default:
-		return nil, fmt.Errorf("unexpected counter return type: %T", out[0])
-	}
+	return count, nil
 }
 
-func (s *Service) Count() (CountResponse, error) {
-	count, err := s.Counter()
+func (s *Service) Count(ctx context.Context) (CountResponse, error) {
+	count, err := s.Counter(ctx)
 	if err != nil {
 		return CountResponse{}, fmt.Errorf("get counter: %w", err)
 	}
 	return CountResponse{
 		Count: int(count.Int64()),
-		Total: int(s.config.NFT.MaxScanTokenID),
+		Total: int(s.col.MaxScanTokenID),
 	}, nil
 }
 
-func (s *Service) Balance() (BalanceResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Service) Balance(ctx context.Context) (BalanceResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
 	defer cancel()
 
 	backend := s.client.Backend()
-	balance, err := backend.BalanceAt(ctx, s.contract, nil)
+	balance, err := backend.BalanceAt(ctx, s.con.Address(), nil)
 	if err != nil {
 		return BalanceResponse{}, fmt.Errorf("get balance: %w", err)
 	}
 	return BalanceResponse{
-		Contract:   s.contract.Hex(),
+		Contract:   s.con.Address().Hex(),
 		Balance:    balance.String(),
 		BalanceETH: ethcli.WeiToEtherString(balance),
 	}, nil
 }
 
 // TokenURI 讀取合約指定 tokenId 的 tokenURI
-func (s *Service) TokenURI(tokenID *big.Int) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *Service) TokenURI(ctx context.Context, tokenID *big.Int) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Call)
 	defer cancel()
 
-	backend := s.client.ConBackend()
-	bound := bind.NewBoundContract(s.contract, s.abi, backend, nil, nil)
-
-	var out []interface{}
-	if err := bound.Call(&bind.CallOpts{Context: ctx}, &out, "tokenURI", tokenID); err != nil {
+	uri, err := s.con.TokenURI(&bind.CallOpts{Context: ctx}, tokenID)
+	if err != nil {
 		return "", fmt.Errorf("tokenURI call failed: %w", err)
 	}
-	if len(out) == 0 {
-		return "", fmt.Errorf("tokenURI returned no result")
-	}
+	return uri, nil
+}
 
-	uri, ok := out[0].(string)
-	if !ok {
-		return "", fmt.Errorf("unexpected tokenURI return type: %T", out[0])
+// isOpened 合約是否已開盲盒：合約未提供 view，未開盒時 tokenURI 一律回傳以 / 結尾的 _baseURI()，
+// 且不檢查 token 是否存在，因此只需查一次 tokenId 0
+func (s *Service) isOpened(ctx context.Context) (bool, error) {
+	uri, err := s.con.TokenURI(&bind.CallOpts{Context: ctx}, big.NewInt(0))
+	if err != nil {
+		return false, fmt.Errorf("tokenURI call failed: %w", err)
 	}
-	return uri, nil
+	return !strings.HasSuffix(uri, "/"), nil
 }
 
 // TokensOfOwner 線性掃描 ownerOf 取得某地址擁有的 tokenIds（因合約未提供 Enumerable）。
-// maxScan<=0 時，優先使用 config.NFT.MaxScanTokenID；若也未設定，預設 1000。
-func (s *Service) TokensOfOwner(req TokensOfOwnerRequest) (TokensOfOwnerResponse, error) {
+// maxScan<=0 時，優先使用合集設定的 MaxScanTokenID；若也未設定，預設 1000。
+func (s *Service) TokensOfOwner(ctx context.Context, req TokensOfOwnerRequest) (TokensOfOwnerResponse, error) {
 	owner := s.client.From()
 	if !gethcommon.IsHexAddress(owner.Hex()) {
 		return TokensOfOwnerResponse{}, fmt.Errorf("invalid address")
@@ -312,14 +419,11 @@ func (s *Service) TokensOfOwner(req TokensOfOwnerRequest) (TokensOfOwnerResponse
 		return TokensOfOwnerResponse{}, fmt.Errorf("address cannot be zero address")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Scan)
 	defer cancel()
 
-	backend := s.client.ConBackend()
-	bound := bind.NewBoundContract(s.contract, s.abi, backend, nil, nil)
-
 	// 1. 先問合約目前的 counter
-	total, err := s.Counter()
+	total, err := s.Counter(ctx)
 	if err != nil {
 		return TokensOfOwnerResponse{}, fmt.Errorf("get counter: %w", err)
 	}
@@ -329,23 +433,16 @@ func (s *Service) TokensOfOwner(req TokensOfOwnerRequest) (TokensOfOwnerResponse
 	for i := int64(0); i <= total.Int64(); i++ {
 		tokenID := big.NewInt(i)
 
-		var out []interface{}
-		if err := bound.Call(&bind.CallOpts{Context: ctx}, &out, "ownerOf", tokenID); err != nil {
+		addr, err := s.con.OwnerOf(&bind.CallOpts{Context: ctx}, tokenID)
+		if err != nil {
 			// token 尚未被 mint，會 revert → 忽略錯誤繼續
 			continue
 		}
-		if len(out) == 0 {
-			continue
-		}
-		addr, ok := out[0].(gethcommon.Address)
-		if !ok {
-			continue
-		}
 		if addr == owner {
 			ids = append(ids, tokenID)
 		}
 	}
-	log.Printf("[nft] TokensOfOwner found tokens: %+v owned by %s", ids, owner.Hex())
+	slog.DebugContext(ctx, "[nft] TokensOfOwner found tokens", "ids", ids, "owner", owner.Hex())
 
 	//3.找尋TokenURI（如果需要）
 	intIDs := make([]int, 0, len(ids))
@@ -354,21 +451,9 @@ func (s *Service) TokensOfOwner(req TokensOfOwnerRequest) (TokensOfOwnerResponse
 	}
 	items := make([]models.TokenItem, 0, len(intIDs))
 	if req.IncludeTokenURI {
-		now := time.Now()
-		deadline := time.Date(2025, 10, 1, 0, 0, 0, 0, time.Local) // 2025年10月1日
-		if now.Before(deadline) {
-			box, err := s.DB.GetBoxItem()
-			if err != nil {
-				return TokensOfOwnerResponse{}, fmt.Errorf("GetBoxItem: %w", err)
-			}
-			for _, id := range intIDs {
-				items = append(items, models.TokenItem{TokenID: strconv.Itoa(id), TokenURI: box.TokenURI, ImageURI: box.ImageURI})
-			}
-		} else {
-			items, err = s.DB.GetTokenItem(intIDs)
-			if err != nil {
-				return TokensOfOwnerResponse{}, fmt.Errorf("GetTokenItem: %w", err)
-			}
+		items, err = s.tokenItems(ctx, intIDs)
+		if err != nil {
+			return TokensOfOwnerResponse{}, err
 		}
 	} else {
 		for _, id := range intIDs {
@@ -0,0 +1,183 @@
+package nft
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/wkchen007/nftweb-back/internal/ethcli"
+)
+
+const testGasLimit = 90000
+
+// fakeChain 記憶體版 ChainClient：以固定私鑰簽名，送出的交易記錄下來並交給 FakeContract 生效
+type fakeChain struct {
+	key   *ecdsa.PrivateKey
+	con   *FakeContract
+	txMu  sync.Mutex
+	mu    sync.Mutex
+	nonce uint64
+	sent  []*types.Transaction
+
+	estimateErr error
+}
+
+func newFakeChain(t *testing.T) *fakeChain {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeChain{key: key}
+}
+
+func (c *fakeChain) Chain() ethcli.Chain      { return ethcli.Chain{ID: 1337, Name: "test"} }
+func (c *fakeChain) ChainID() *big.Int        { return big.NewInt(1337) }
+func (c *fakeChain) Network() string          { return "test" }
+func (c *fakeChain) Backend() ethcli.Backend  { return fakeBackend{c: c} }
+func (c *fakeChain) From() gethcommon.Address { return crypto.PubkeyToAddress(c.key.PublicKey) }
+func (c *fakeChain) LockTx()                  { c.txMu.Lock() }
+func (c *fakeChain) UnlockTx()                { c.txMu.Unlock() }
+
+func (c *fakeChain) NewTransactor(ctx context.Context) (*bind.TransactOpts, error) {
+	opts := bind.NewKeyedTransactor(c.key, c.ChainID())
+	c.mu.Lock()
+	opts.Nonce = new(big.Int).SetUint64(c.nonce)
+	c.mu.Unlock()
+	opts.GasTipCap = big.NewInt(1)
+	opts.GasFeeCap = big.NewInt(2)
+	opts.Context = ctx
+	return opts, nil
+}
+
+// fakeBackend 只實作送交易用到的 RPC，其餘保持 nil
+type fakeBackend struct {
+	ethcli.Backend
+	c *fakeChain
+}
+
+func (b fakeBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	if b.c.estimateErr != nil {
+		return 0, b.c.estimateErr
+	}
+	return testGasLimit, nil
+}
+
+func (b fakeBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.c.con.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.c.mu.Lock()
+	defer b.c.mu.Unlock()
+	b.c.sent = append(b.c.sent, tx)
+	b.c.nonce++
+	return nil
+}
+
+func (c *fakeChain) WaitMined(ctx context.Context, txHash string) (*types.Receipt, error) {
+	return &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: gethcommon.HexToHash(txHash)}, nil
+}
+
+func newTestService(t *testing.T) (*Service, *FakeContract, *fakeChain) {
+	t.Helper()
+	chain := newFakeChain(t)
+	con := NewFakeContract(gethcommon.HexToAddress("0x00000000000000000000000000000000000000aa"), chain.From(), 10)
+	chain.con = con
+	svc, err := NewServiceWithContract(chain, con, "0x"+strings.Repeat("0", 64), CollectionConfig{Slug: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc, con, chain
+}
+
+func TestMintAndWithdraw(t *testing.T) {
+	ctx := context.Background()
+	svc, con, chain := newTestService(t)
+
+	res, err := svc.Mint(ctx, MintRequest{Amount: "2"})
+	if err != nil {
+		t.Fatalf("mint: %v", err)
+	}
+	if len(chain.sent) != 1 {
+		t.Fatalf("sent %d txs, want 1", len(chain.sent))
+	}
+	tx := chain.sent[0]
+	if tx.Hash().Hex() != res.TxHash {
+		t.Errorf("tx hash = %s, want %s", tx.Hash().Hex(), res.TxHash)
+	}
+	if tx.Gas() != testGasLimit {
+		t.Errorf("gas = %d, want %d", tx.Gas(), testGasLimit)
+	}
+	if tx.Value().Cmp(big.NewInt(2e16)) != 0 {
+		t.Errorf("value = %s, want 2e16", tx.Value())
+	}
+	if sender, err := types.Sender(types.LatestSignerForChainID(chain.ChainID()), tx); err != nil || sender != chain.From() {
+		t.Errorf("sender = %s (%v), want %s", sender.Hex(), err, chain.From().Hex())
+	}
+	for id := int64(0); id < 2; id++ {
+		owner, err := con.OwnerOf(nil, big.NewInt(id))
+		if err != nil || owner != chain.From() {
+			t.Errorf("ownerOf(%d) = %s (%v), want %s", id, owner.Hex(), err, chain.From().Hex())
+		}
+	}
+	if got := con.ContractBalance(); got.Cmp(big.NewInt(2e16)) != 0 {
+		t.Errorf("contract balance = %s, want 2e16", got)
+	}
+
+	if _, err := svc.Withdraw(ctx); err != nil {
+		t.Fatalf("withdraw: %v", err)
+	}
+	if len(chain.sent) != 2 {
+		t.Fatalf("sent %d txs, want 2", len(chain.sent))
+	}
+	if chain.sent[1].Nonce() != 1 {
+		t.Errorf("withdraw nonce = %d, want 1", chain.sent[1].Nonce())
+	}
+	if got := con.ContractBalance(); got.Sign() != 0 {
+		t.Errorf("contract balance after withdraw = %s, want 0", got)
+	}
+}
+
+func TestMintRevertIsNotSent(t *testing.T) {
+	svc, con, chain := newTestService(t)
+
+	_, err := svc.Mint(context.Background(), MintRequest{Amount: "1", ValueETH: "0.02"})
+	if err == nil || !strings.Contains(err.Error(), "incorrect payment") {
+		t.Fatalf("mint with wrong value: err = %v, want incorrect payment", err)
+	}
+	if len(chain.sent) != 0 {
+		t.Errorf("sent %d txs, want 0", len(chain.sent))
+	}
+	if n, _ := con.Counter(nil); n.Sign() != 0 {
+		t.Errorf("counter = %s, want 0", n)
+	}
+}
+
+func TestWithdrawEstimateGasError(t *testing.T) {
+	ctx := context.Background()
+	svc, con, chain := newTestService(t)
+	if _, err := svc.Mint(ctx, MintRequest{Amount: "1", ValueETH: "0.01"}); err != nil {
+		t.Fatalf("mint: %v", err)
+	}
+	chain.estimateErr = errors.New("execution reverted")
+
+	_, err := svc.Withdraw(ctx)
+	if err == nil || !strings.Contains(err.Error(), "estimate gas") || !errors.Is(err, chain.estimateErr) {
+		t.Fatalf("withdraw: err = %v, want wrapped estimate gas error", err)
+	}
+	if len(chain.sent) != 1 {
+		t.Errorf("sent %d txs, want only the mint", len(chain.sent))
+	}
+	if got := con.ContractBalance(); got.Cmp(big.NewInt(1e16)) != 0 {
+		t.Errorf("contract balance = %s, want 1e16 (withdraw not applied)", got)
+	}
+}
@@ -0,0 +1,208 @@
+package nft
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/wkchen007/nftweb-back/internal/models"
+)
+
+// Snapshot 計算 block 高度時每個 token 的持有者。block 為 0 代表最新區塊，超過最新區塊時回傳錯誤。
+// 先以 ownerOf 帶 CallOpts.BlockNumber 查詢（需 archive 節點）；失敗時改為重播 Transfer log 到該區塊
+func (s *Service) Snapshot(ctx context.Context, block uint64) (models.HolderSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Snapshot)
+	defer cancel()
+
+	head, err := s.client.Backend().BlockNumber(ctx)
+	if err != nil {
+		return models.HolderSnapshot{}, fmt.Errorf("get block number: %w", err)
+	}
+	if block == 0 {
+		block = head
+	}
+	if block > head {
+		return models.HolderSnapshot{}, invalidInput("block %d is beyond the latest block %d", block, head)
+	}
+
+	source := models.SnapshotSourceOwnerOf
+	owners, err := s.ownersAtByCall(ctx, block)
+	if err != nil {
+		slog.WarnContext(ctx, "[nft] snapshot ownerOf failed, replaying transfer logs", "block", block, "err", err)
+		source = models.SnapshotSourceTransfer
+		owners, err = s.ownersAtByLogs(ctx, block)
+		if err != nil {
+			return models.HolderSnapshot{}, err
+		}
+	}
+
+	return models.HolderSnapshot{
+		Contract:    s.con.Address().Hex(),
+		BlockNumber: block,
+		Source:      source,
+		Holders:     groupHolders(owners),
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+func (s *Service) ownersAtByCall(ctx context.Context, block uint64) (map[uint64]gethcommon.Address, error) {
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(block)}
+
+	total, err := s.con.Counter(opts)
+	if err != nil {
+		return nil, fmt.Errorf("counter at block %d: %w", block, err)
+	}
+
+	owners := map[uint64]gethcommon.Address{}
+	for i := uint64(0); i < total.Uint64(); i++ {
+		addr, err := s.con.OwnerOf(opts, new(big.Int).SetUint64(i))
+		if err != nil {
+			return nil, fmt.Errorf("ownerOf %d at block %d: %w", i, block, err)
+		}
+		owners[i] = addr
+	}
+	return owners, nil
+}
+
+func (s *Service) ownersAtByLogs(ctx context.Context, block uint64) (map[uint64]gethcommon.Address, error) {
+	start, err := s.deployBlock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if block < start {
+		return nil, fmt.Errorf("block %d is before contract deployment (%d)", block, start)
+	}
+
+	transfers, err := s.filterTransfers(ctx, start, block)
+	if err != nil {
+		return nil, err
+	}
+	owners := map[uint64]gethcommon.Address{}
+	for _, e := range transfers {
+		owners[e.TokenID.Uint64()] = e.To
+	}
+	return owners, nil
+}
+
+// groupHolders 依地址彙整 token，排除零地址（已銷毀），依持有數量由多到少排序
+func groupHolders(owners map[uint64]gethcommon.Address) []models.SnapshotHolder {
+	ids := map[gethcommon.Address][]uint64{}
+	for id, addr := range owners {
+		if addr == (gethcommon.Address{}) {
+			continue
+		}
+		ids[addr] = append(ids[addr], id)
+	}
+
+	holders := make([]models.SnapshotHolder, 0, len(ids))
+	for addr, list := range ids {
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+		h := models.SnapshotHolder{Address: addr.Hex(), Count: len(list)}
+		for _, id := range list {
+			h.TokenIDs = append(h.TokenIDs, strconv.FormatUint(id, 10))
+		}
+		holders = append(holders, h)
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if holders[i].Count != holders[j].Count {
+			return holders[i].Count > holders[j].Count
+		}
+		return holders[i].Address < holders[j].Address
+	})
+	return holders
+}
+
+// WriteSnapshotCSV 輸出 address,count,tokenIds（tokenIds 以 ; 分隔）
+func WriteSnapshotCSV(w io.Writer, snap models.HolderSnapshot) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"address", "count", "tokenIds"}); err != nil {
+		return err
+	}
+	for _, h := range snap.Holders {
+		if err := cw.Write([]string{h.Address, strconv.Itoa(h.Count), strings.Join(h.TokenIDs, ";")}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type HolderChange struct {
+	Address string   `json:"address"`
+	Before  int      `json:"before"`
+	After   int      `json:"after"`
+	Gained  []string `json:"gained,omitempty"`
+	Lost    []string `json:"lost,omitempty"`
+}
+
+type SnapshotDiff struct {
+	From    int            `json:"from"` // 快照 id
+	To      int            `json:"to"`
+	Added   []HolderChange `json:"added"`   // 新持有者
+	Removed []HolderChange `json:"removed"` // 不再持有
+	Changed []HolderChange `json:"changed"` // 持有的 token 有變動
+}
+
+// DiffSnapshots 比較兩次快照的持有者差異
+func DiffSnapshots(a, b models.HolderSnapshot) SnapshotDiff {
+	before := map[string]models.SnapshotHolder{}
+	for _, h := range a.Holders {
+		before[h.Address] = h
+	}
+	after := map[string]models.SnapshotHolder{}
+	for _, h := range b.Holders {
+		after[h.Address] = h
+	}
+
+	diff := SnapshotDiff{From: a.ID, To: b.ID, Added: []HolderChange{}, Removed: []HolderChange{}, Changed: []HolderChange{}}
+	for addr, h := range after {
+		old, ok := before[addr]
+		gained, lost := diffIDs(old.TokenIDs, h.TokenIDs)
+		change := HolderChange{Address: addr, Before: old.Count, After: h.Count, Gained: gained, Lost: lost}
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, change)
+		case len(gained) > 0 || len(lost) > 0:
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for addr, h := range before {
+		if _, ok := after[addr]; !ok {
+			diff.Removed = append(diff.Removed, HolderChange{Address: addr, Before: h.Count, Lost: h.TokenIDs})
+		}
+	}
+
+	for _, list := range [][]HolderChange{diff.Added, diff.Removed, diff.Changed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Address < list[j].Address })
+	}
+	return diff
+}
+
+func diffIDs(before, after []string) (gained, lost []string) {
+	had := map[string]bool{}
+	for _, id := range before {
+		had[id] = true
+	}
+	has := map[string]bool{}
+	for _, id := range after {
+		has[id] = true
+		if !had[id] {
+			gained = append(gained, id)
+		}
+	}
+	for _, id := range before {
+		if !has[id] {
+			lost = append(lost, id)
+		}
+	}
+	return gained, lost
+}
@@ -0,0 +1,201 @@
+package nft
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/wkchen007/nftweb-back/internal/ethcli"
+)
+
+type HolderStat struct {
+	Address string `json:"address"`
+	Count   int    `json:"count"`
+}
+
+type DailyMint struct {
+	Date  string `json:"date"` // UTC，格式 2006-01-02
+	Count int    `json:"count"`
+}
+
+type StatsResponse struct {
+	Contract      string       `json:"contract"`
+	MaxSupply     int          `json:"maxSupply"`
+	Minted        int          `json:"minted"`
+	Remaining     int          `json:"remaining"`
+	UniqueHolders int          `json:"uniqueHolders"`
+	Holders       []HolderStat `json:"holders"`      // 依持有數量由多到少
+	Distribution  map[int]int  `json:"distribution"` // 持有 N 個的地址數
+	MintsPerDay   []DailyMint  `json:"mintsPerDay"`
+	RevenueWei    string       `json:"revenueWei"`
+	RevenueETH    string       `json:"revenueETH"`
+	BalanceWei    string       `json:"balanceWei"`
+	BalanceETH    string       `json:"balanceETH"`
+	Revealed      int          `json:"revealed"`
+	Unrevealed    int          `json:"unrevealed"`
+}
+
+// Stats 由鏈上資料計算合集統計：Transfer 事件重播出持有者，counter / maxSupply 算供給，
+// 營收以合集設定的 mint 單價乘上已鑄造數量（合約要求 msg.value 完全相等）
+func (s *Service) Stats(ctx context.Context) (StatsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Scan)
+	defer cancel()
+	opts := &bind.CallOpts{Context: ctx}
+
+	minted, err := s.con.Counter(opts)
+	if err != nil {
+		return StatsResponse{}, fmt.Errorf("get counter: %w", err)
+	}
+	maxSupply, err := s.con.MaxSupply(opts)
+	if err != nil {
+		return StatsResponse{}, fmt.Errorf("get maxSupply: %w", err)
+	}
+
+	start, err := s.deployBlock(ctx)
+	if err != nil {
+		return StatsResponse{}, err
+	}
+	head, err := s.client.Backend().BlockNumber(ctx)
+	if err != nil {
+		return StatsResponse{}, fmt.Errorf("get block number: %w", err)
+	}
+	transfers, err := s.filterTransfers(ctx, start, head)
+	if err != nil {
+		return StatsResponse{}, err
+	}
+
+	// 重播 Transfer 取得目前持有者，同時統計每日鑄造量
+	owners := map[string]gethcommon.Address{}
+	daily := map[string]int{}
+	blockDay := map[uint64]string{}
+	for _, e := range transfers {
+		owners[e.TokenID.String()] = e.To
+		if e.From != (gethcommon.Address{}) {
+			continue
+		}
+		day, ok := blockDay[e.Raw.BlockNumber]
+		if !ok {
+			t, err := s.blockTime(ctx, e.Raw.BlockNumber)
+			if err != nil {
+				return StatsResponse{}, err
+			}
+			day = t.UTC().Format("2006-01-02")
+			blockDay[e.Raw.BlockNumber] = day
+		}
+		daily[day]++
+	}
+
+	counts := map[gethcommon.Address]int{}
+	for _, o := range owners {
+		if o != (gethcommon.Address{}) {
+			counts[o]++
+		}
+	}
+	holders := make([]HolderStat, 0, len(counts))
+	dist := map[int]int{}
+	for addr, n := range counts {
+		holders = append(holders, HolderStat{Address: addr.Hex(), Count: n})
+		dist[n]++
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if holders[i].Count != holders[j].Count {
+			return holders[i].Count > holders[j].Count
+		}
+		return holders[i].Address < holders[j].Address
+	})
+
+	days := make([]DailyMint, 0, len(daily))
+	for d, n := range daily {
+		days = append(days, DailyMint{Date: d, Count: n})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+
+	// 合約一次開盒全部 token，開盒後已鑄造的都算已開
+	opened, err := s.isOpened(ctx)
+	if err != nil {
+		return StatsResponse{}, err
+	}
+	revealed := 0
+	if opened {
+		revealed = int(minted.Int64())
+	}
+
+	balance, err := s.Balance(ctx)
+	if err != nil {
+		return StatsResponse{}, err
+	}
+	revenue := new(big.Int).Mul(s.price, minted)
+
+	return StatsResponse{
+		Contract:      s.con.Address().Hex(),
+		MaxSupply:     int(maxSupply.Int64()),
+		Minted:        int(minted.Int64()),
+		Remaining:     int(new(big.Int).Sub(maxSupply, minted).Int64()),
+		UniqueHolders: len(holders),
+		Holders:       holders,
+		Distribution:  dist,
+		MintsPerDay:   days,
+		RevenueWei:    revenue.String(),
+		RevenueETH:    ethcli.WeiToEtherString(revenue),
+		BalanceWei:    balance.Balance,
+		BalanceETH:    balance.BalanceETH,
+		Revealed:      revealed,
+		Unrevealed:    int(minted.Int64()) - revealed,
+	}, nil
+}
+
+// logRange 單次查詢 log 的區塊數上限，多數 RPC 供應商會拒絕範圍過大的 eth_getLogs
+const logRange = 5000
+
+// filterTransfers 以 logRange 分段查詢 [start, end] 的 Transfer 事件
+func (s *Service) filterTransfers(ctx context.Context, start, end uint64) ([]TransferEvent, error) {
+	var all []TransferEvent
+	for from := start; from <= end; from += logRange {
+		to := min(from+logRange-1, end)
+		page, err := s.con.FilterTransfer(&bind.FilterOpts{Start: from, End: &to, Context: ctx}, nil, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("filter transfer [%d, %d]: %w", from, to, err)
+		}
+		all = append(all, page...)
+	}
+	return all, nil
+}
+
+// deployBlock 由 contractTxHash 的收據取得合約部署區塊，作為掃描事件的起點
+func (s *Service) deployBlock(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conBlock != 0 {
+		return s.conBlock, nil
+	}
+	receipt, err := s.client.Backend().TransactionReceipt(ctx, s.conTxHash)
+	if err != nil {
+		return 0, fmt.Errorf("get contract creation receipt: %w", err)
+	}
+	s.conBlock = receipt.BlockNumber.Uint64()
+	return s.conBlock, nil
+}
+
+// blockTime 區塊時間；查過的區塊留在記憶體，/stats 不會每次都對每個鑄造區塊呼叫 RPC
+func (s *Service) blockTime(ctx context.Context, number uint64) (time.Time, error) {
+	s.mu.Lock()
+	t, ok := s.blockTimes[number]
+	s.mu.Unlock()
+	if ok {
+		return t, nil
+	}
+
+	h, err := s.client.Backend().HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get block %d: %w", number, err)
+	}
+	t = time.Unix(int64(h.Time), 0)
+	s.mu.Lock()
+	s.blockTimes[number] = t
+	s.mu.Unlock()
+	return t, nil
+}
@@ -0,0 +1,49 @@
+package nft
+
+import "time"
+
+// Timeouts 各類鏈上操作的上限；都建立在呼叫端的 ctx（request context）之上，
+// client 斷線或服務關閉時會提早取消
+type Timeouts struct {
+	Call     time.Duration `yaml:"call"`     // 單次唯讀呼叫（ownerOf、tokenURI、balance）
+	Tx       time.Duration `yaml:"tx"`       // 送出一筆交易（nonce、估 gas、簽章、送出）
+	Scan     time.Duration `yaml:"scan"`     // 逐一掃描 token（tokensOfOwner、stats）
+	Snapshot time.Duration `yaml:"snapshot"` // 持有者快照
+	Poll     time.Duration `yaml:"poll"`     // 每輪事件輪詢
+}
+
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Call:     5 * time.Second,
+		Tx:       30 * time.Second,
+		Scan:     30 * time.Second,
+		Snapshot: 60 * time.Second,
+		Poll:     30 * time.Second,
+	}
+}
+
+// withDefaults 未設定（<= 0）的欄位使用預設值
+func (t Timeouts) withDefaults() Timeouts {
+	def := DefaultTimeouts()
+	if t.Call <= 0 {
+		t.Call = def.Call
+	}
+	if t.Tx <= 0 {
+		t.Tx = def.Tx
+	}
+	if t.Scan <= 0 {
+		t.Scan = def.Scan
+	}
+	if t.Snapshot <= 0 {
+		t.Snapshot = def.Snapshot
+	}
+	if t.Poll <= 0 {
+		t.Poll = def.Poll
+	}
+	return t
+}
+
+// SetTimeouts 設定操作上限（未設定的欄位使用預設值）
+func (s *Service) SetTimeouts(t Timeouts) {
+	s.timeouts = t.withDefaults()
+}
@@ -1,73 +1,17 @@
 package nft
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
+
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
 )
 
-type JSONResponse struct {
-	Error   bool        `json:"error"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
+type JSONResponse = httpapi.JSONResponse
 
 func (h *Handlers) writeJSON(w http.ResponseWriter, status int, data interface{}, headers ...http.Header) error {
-	out, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	if len(headers) > 0 {
-		for key, value := range headers[0] {
-			w.Header()[key] = value
-		}
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_, err = w.Write(out)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return httpapi.WriteJSON(w, status, data, headers...)
 }
 
 func (h *Handlers) readJSON(w http.ResponseWriter, r *http.Request, data interface{}) error {
-	maxBytes := 1024 * 1024 // one megabyte
-	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
-
-	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields()
-
-	err := dec.Decode(data)
-	if err == io.EOF {
-		return fmt.Errorf("no request body")
-	}
-	if err != nil {
-		return err
-	}
-
-	err = dec.Decode(&struct{}{})
-	if err != io.EOF {
-		return fmt.Errorf("body must only contain a single JSON value")
-	}
-
-	return nil
-}
-
-func (h *Handlers) errorJSON(w http.ResponseWriter, err error, status ...int) error {
-	statusCode := http.StatusBadRequest
-
-	if len(status) > 0 {
-		statusCode = status[0]
-	}
-
-	var payload JSONResponse
-	payload.Error = true
-	payload.Message = err.Error()
-
-	return h.writeJSON(w, statusCode, payload)
+	return httpapi.ReadJSON(w, r, data)
 }
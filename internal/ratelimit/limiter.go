@@ -0,0 +1,222 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Rule 一個路由群組的上限：window 內最多 limit 次
+type Rule struct {
+	Limit  int           `yaml:"limit"`
+	Window time.Duration `yaml:"window"`
+	Key    string        `yaml:"key"` // user（預設，有登入用 user id，否則用 IP）或 ip
+}
+
+const (
+	KeyUser = "user"
+	KeyIP   = "ip"
+)
+
+// Config 各路由群組的規則；群組沒有設定規則時不限制
+type Config struct {
+	Enabled        bool            `yaml:"enabled"`
+	Groups         map[string]Rule `yaml:"groups"`
+	Allowlist      []string        `yaml:"allowlist"`      // 不受限制的內部來源（IP 或 CIDR）
+	TrustedProxies []string        `yaml:"trustedProxies"` // 只有來自這些位址時才採用 X-Forwarded-For
+}
+
+// 路由群組
+const (
+	GroupDefault = "default" // 所有請求
+	GroupAuth    = "auth"    // 登入 / 登出
+	GroupScan    = "scan"    // tokensOfOwner、stats、快照等會大量呼叫 RPC 的查詢
+	GroupWallet  = "wallet"  // 送交易
+)
+
+func DefaultConfig() Config {
+	return Config{
+		Enabled: true,
+		Groups: map[string]Rule{
+			GroupDefault: {Limit: 300, Window: time.Minute},
+			GroupAuth:    {Limit: 10, Window: time.Minute, Key: KeyIP},
+			GroupScan:    {Limit: 10, Window: time.Minute},
+			GroupWallet:  {Limit: 20, Window: time.Minute},
+		},
+		Allowlist: []string{"127.0.0.1", "::1"},
+	}
+}
+
+func (c Config) Validate() error {
+	for name, r := range c.Groups {
+		if r.Limit <= 0 || r.Window <= 0 {
+			return fmt.Errorf("groups.%s: limit and window must be positive", name)
+		}
+		if r.Key != "" && r.Key != KeyUser && r.Key != KeyIP {
+			return fmt.Errorf("groups.%s: key must be %s or %s", name, KeyUser, KeyIP)
+		}
+	}
+	if _, err := parseNets(c.Allowlist); err != nil {
+		return fmt.Errorf("allowlist: %w", err)
+	}
+	if _, err := parseNets(c.TrustedProxies); err != nil {
+		return fmt.Errorf("trustedProxies: %w", err)
+	}
+	return nil
+}
+
+// Result 一次檢查的結果，對應 RateLimit-* header
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration // 最舊的一筆離開 window 的時間，被拒絕時即 Retry-After
+}
+
+// slidingWindow 以 sorted set 記錄 window 內每次請求的時間（Redis 伺服器時間，避免多台 API 時鐘不一致）；
+// 回傳 {allowed, remaining, reset_ms}
+var slidingWindow = redis.NewScript(`
+local key = KEYS[1]
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, now .. '-' .. ARGV[3])
+	redis.call('PEXPIRE', key, window)
+	count = count + 1
+	allowed = 1
+end
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local reset = 0
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+// Limiter 以 Redis sliding window 計數，多台 API 共用同一份額度
+type Limiter struct {
+	rdb       *redis.Client
+	rules     map[string]Rule
+	allowlist []*net.IPNet
+	proxies   []*net.IPNet
+}
+
+func New(rdb *redis.Client, cfg Config) (*Limiter, error) {
+	allow, err := parseNets(cfg.Allowlist)
+	if err != nil {
+		return nil, err
+	}
+	proxies, err := parseNets(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	rules := map[string]Rule{}
+	if cfg.Enabled {
+		rules = cfg.Groups
+	}
+	return &Limiter{rdb: rdb, rules: rules, allowlist: allow, proxies: proxies}, nil
+}
+
+// Rule 群組的規則，未設定時 ok 為 false
+func (l *Limiter) Rule(group string) (Rule, bool) {
+	r, ok := l.rules[group]
+	return r, ok
+}
+
+// Allowed ip 是否在 allowlist
+func (l *Limiter) Allowed(ip net.IP) bool {
+	return contains(l.allowlist, ip)
+}
+
+// Take 在 group 的 key（例如 user:12 或 ip:1.2.3.4）上記一次
+func (l *Limiter) Take(ctx context.Context, group, key string, rule Rule) (Result, error) {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	res, err := slidingWindow.Run(ctx, l.rdb, []string{"ratelimit:" + group + ":" + key},
+		rule.Window.Milliseconds(), rule.Limit, hex.EncodeToString(b)).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:   res[0] == 1,
+		Limit:     rule.Limit,
+		Remaining: int(res[1]),
+		Reset:     time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
+
+// ClientIP 請求的來源 IP；RemoteAddr 是信任的 proxy 時，取 X-Forwarded-For 中最右邊不是 proxy 的位址
+func (l *Limiter) ClientIP(remoteAddr, forwardedFor string) net.IP {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !contains(l.proxies, ip) || forwardedFor == "" {
+		return ip
+	}
+
+	hops := strings.Split(forwardedFor, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !contains(l.proxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// parseNets 解析 IP 或 CIDR 清單
+func parseNets(list []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip %q", s)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import "testing"
+
+func TestClientIP(t *testing.T) {
+	l, err := New(nil, Config{TrustedProxies: []string{"10.0.0.0/8", "2001:db8::1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		want         string
+	}{
+		{"no proxy", "203.0.113.5:1234", "", "203.0.113.5"},
+		{"untrusted remote ignores xff", "203.0.113.5:1234", "198.51.100.1", "203.0.113.5"},
+		{"trusted proxy without xff", "10.0.0.2:1234", "", "10.0.0.2"},
+		{"trusted proxy", "10.0.0.2:1234", "198.51.100.1", "198.51.100.1"},
+		{"spoofed leftmost hop", "10.0.0.2:1234", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"chained proxies", "10.0.0.2:1234", "198.51.100.1, 10.0.0.3, 10.0.0.4", "198.51.100.1"},
+		{"spaces around hops", "10.0.0.2:1234", " 198.51.100.1 ,10.0.0.3 ", "198.51.100.1"},
+		{"all hops are proxies", "10.0.0.2:1234", "10.0.0.3, 10.0.0.4", "10.0.0.3"},
+		{"invalid hop stops the walk", "10.0.0.2:1234", "198.51.100.1, garbage", "10.0.0.2"},
+		{"invalid hop behind client", "10.0.0.2:1234", "garbage, 198.51.100.1", "198.51.100.1"},
+		{"ipv6 proxy", "[2001:db8::1]:443", "2001:db8::99", "2001:db8::99"},
+		{"ipv6 untrusted", "[2001:db8::2]:443", "198.51.100.1", "2001:db8::2"},
+		{"remote without port", "10.0.0.2", "198.51.100.1", "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := l.ClientIP(tt.remoteAddr, tt.forwardedFor)
+			if got.String() != tt.want {
+				t.Fatalf("ClientIP(%q, %q) = %s, want %s", tt.remoteAddr, tt.forwardedFor, got, tt.want)
+			}
+		})
+	}
+
+	// 沒有設定 trustedProxies 時一律不採用 X-Forwarded-For
+	l, err = New(nil, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.ClientIP("10.0.0.2:1234", "198.51.100.1"); got.String() != "10.0.0.2" {
+		t.Fatalf("ClientIP without trusted proxies = %s, want 10.0.0.2", got)
+	}
+}
@@ -0,0 +1,219 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log/slog"
+
+	"github.com/wkchen007/nftweb-back/internal/models"
+	"github.com/wkchen007/nftweb-back/internal/repository"
+)
+
+// airdropLockClass pg_advisory_lock(classid, objid) 的 classid，避免與其他用途的 advisory lock 撞號
+const airdropLockClass = 1001
+
+// InsertAirdrop 在同一個交易內寫入批次與每一列
+func (m *PostgresDBRepo) InsertAirdrop(ctx context.Context, a models.Airdrop) (int, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `insert into airdrops (contract, status, total_amount, value_wei, created_at, updated_at)
+			values ($1, $2, $3, $4, now(), now()) returning id`
+
+	var id int
+	err = tx.QueryRowContext(ctx, stmt, a.Contract, a.Status, a.TotalAmount, a.ValueWei).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, row := range a.Rows {
+		_, err := tx.ExecContext(ctx,
+			`insert into airdrop_rows (airdrop_id, row_no, address, amount, status, tx_hash, error, updated_at)
+			values ($1, $2, $3, $4, $5, $6, $7, now())`,
+			id,
+			row.RowNo,
+			row.Address,
+			row.Amount,
+			row.Status,
+			row.TxHash,
+			row.Error,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (m *PostgresDBRepo) GetAirdrop(ctx context.Context, id int) (*models.Airdrop, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `select id, contract, status, total_amount, value_wei, created_at, updated_at
+			from airdrops where id = $1`
+
+	var a models.Airdrop
+	row := m.DB.QueryRowContext(ctx, query, id)
+	err := row.Scan(
+		&a.ID,
+		&a.Contract,
+		&a.Status,
+		&a.TotalAmount,
+		&a.ValueWei,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `select airdrop_id, row_no, address, amount, status, tx_hash, error
+			from airdrop_rows where airdrop_id = $1 order by row_no`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.AirdropRow
+		var txHash, rowErr sql.NullString
+		err := rows.Scan(
+			&r.AirdropID,
+			&r.RowNo,
+			&r.Address,
+			&r.Amount,
+			&r.Status,
+			&txHash,
+			&rowErr,
+		)
+		if err != nil {
+			return nil, err
+		}
+		r.TxHash = txHash.String
+		r.Error = rowErr.String
+
+		a.Rows = append(a.Rows, r)
+	}
+
+	return &a, nil
+}
+
+// AllAirdrops 列出合約的空投批次，新的在前
+func (m *PostgresDBRepo) AllAirdrops(ctx context.Context, contract string) ([]*models.Airdrop, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		select
+			id, contract, status, total_amount, value_wei, created_at, updated_at
+		from
+			airdrops
+		where
+			lower(contract) = lower($1)
+		order by
+			id desc
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, contract)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var airdrops []*models.Airdrop
+
+	for rows.Next() {
+		var a models.Airdrop
+		err := rows.Scan(
+			&a.ID,
+			&a.Contract,
+			&a.Status,
+			&a.TotalAmount,
+			&a.ValueWei,
+			&a.CreatedAt,
+			&a.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		airdrops = append(airdrops, &a)
+	}
+
+	return airdrops, nil
+}
+
+func (m *PostgresDBRepo) UpdateAirdropStatus(ctx context.Context, id int, status string) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, `update airdrops set status = $1, updated_at = now() where id = $2`, status, id)
+
+	return err
+}
+
+func (m *PostgresDBRepo) UpdateAirdropRow(ctx context.Context, row models.AirdropRow) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	stmt := `update airdrop_rows set status = $1, tx_hash = $2, error = $3, updated_at = now()
+			where airdrop_id = $4 and row_no = $5`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		row.Status,
+		row.TxHash,
+		row.Error,
+		row.AirdropID,
+		row.RowNo,
+	)
+
+	return err
+}
+
+// TryLockAirdrop 以 pg_try_advisory_lock 取得批次的執行權，多台 API 只有一台能執行同一個批次。
+// 鎖綁在一條專用連線上，呼叫 unlock 或程序結束（連線中斷）時釋放；已被持有時回傳 repository.ErrLocked
+func (m *PostgresDBRepo) TryLockAirdrop(ctx context.Context, id int) (func(), error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	err = conn.QueryRowContext(ctx, `select pg_try_advisory_lock($1, $2)`, airdropLockClass, id).Scan(&ok)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, repository.ErrLocked
+	}
+
+	unlock := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+		defer cancel()
+		if _, err := conn.ExecContext(ctx, `select pg_advisory_unlock($1, $2)`, airdropLockClass, id); err != nil {
+			// 解鎖失敗時不放回連線池，關閉連線讓 Postgres 釋放鎖
+			slog.ErrorContext(ctx, "[db] release airdrop lock", "id", id, "err", err)
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return unlock, nil
+}
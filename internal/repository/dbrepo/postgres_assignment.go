@@ -0,0 +1,116 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wkchen007/nftweb-back/internal/models"
+)
+
+// InsertAssignment 寫入 commitment；每個合約只允許一筆
+func (m *PostgresDBRepo) InsertAssignment(ctx context.Context, a models.Assignment) (int, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	pool, err := json.Marshal(a.Pool)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := `insert into assignments (contract, commitment, seed, pool, mapping, status, created_at)
+			values ($1, $2, $3, $4, $5, $6, now()) returning id`
+
+	var id int
+	err = m.DB.QueryRowContext(ctx, stmt,
+		a.Contract,
+		a.Commitment,
+		a.Seed,
+		string(pool),
+		joinInts(a.Mapping),
+		a.Status,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetAssignment 取得合約的分配（含 seed），找不到時回傳 sql.ErrNoRows
+func (m *PostgresDBRepo) GetAssignment(ctx context.Context, contract string) (*models.Assignment, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `select id, contract, commitment, seed, pool, mapping, status, created_at, revealed_at
+			from assignments where contract = $1`
+
+	var a models.Assignment
+	var pool, mapping string
+	var revealedAt sql.NullTime
+	row := m.DB.QueryRowContext(ctx, query, contract)
+	err := row.Scan(
+		&a.ID,
+		&a.Contract,
+		&a.Commitment,
+		&a.Seed,
+		&pool,
+		&mapping,
+		&a.Status,
+		&a.CreatedAt,
+		&revealedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if revealedAt.Valid {
+		a.RevealedAt = &revealedAt.Time
+	}
+	if err := json.Unmarshal([]byte(pool), &a.Pool); err != nil {
+		return nil, fmt.Errorf("assignment %d pool: %w", a.ID, err)
+	}
+	a.Mapping, err = splitInts(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("assignment %d: %w", a.ID, err)
+	}
+
+	return &a, nil
+}
+
+func (m *PostgresDBRepo) RevealAssignment(ctx context.Context, id int) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	stmt := `update assignments set status = $1, revealed_at = now() where id = $2`
+
+	_, err := m.DB.ExecContext(ctx, stmt, models.AssignmentRevealed, id)
+
+	return err
+}
+
+func joinInts(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, ",")
+}
+
+func splitInts(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	ids := make([]int, len(parts))
+	for i, p := range parts {
+		id, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mapping entry %q", p)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
@@ -0,0 +1,257 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/wkchen007/nftweb-back/internal/models"
+	"github.com/wkchen007/nftweb-back/internal/repository"
+)
+
+// CatalogItems 回傳合集的所有商品（含盲盒與非 demo 的項目）
+func (m *PostgresDBRepo) CatalogItems(ctx context.Context, collection string) ([]*models.NFT, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		select
+			id, name, coalesce(description, ''), meta, image, demo = 1, rarity, weight, supply, collection
+		from
+			nft
+		where
+			collection = $1
+		order by
+			id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, collection)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nfts []*models.NFT
+
+	for rows.Next() {
+		var nft models.NFT
+		err := rows.Scan(
+			&nft.ID,
+			&nft.Name,
+			&nft.Desc,
+			&nft.Meta,
+			&nft.Image,
+			&nft.Demo,
+			&nft.Rarity,
+			&nft.Weight,
+			&nft.Supply,
+			&nft.Collection,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		nfts = append(nfts, &nft)
+	}
+
+	return nfts, nil
+}
+
+func (m *PostgresDBRepo) GetNFT(ctx context.Context, collection string, id int) (*models.NFT, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	return getNFT(ctx, m.DB, collection, id)
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func getNFT(ctx context.Context, db queryRower, collection string, id int) (*models.NFT, error) {
+	query := `select id, name, coalesce(description, ''), meta, image, demo = 1, rarity, weight, supply, collection
+			from nft where collection = $1 and id = $2`
+
+	var nft models.NFT
+	row := db.QueryRowContext(ctx, query, collection, id)
+	err := row.Scan(
+		&nft.ID,
+		&nft.Name,
+		&nft.Desc,
+		&nft.Meta,
+		&nft.Image,
+		&nft.Demo,
+		&nft.Rarity,
+		&nft.Weight,
+		&nft.Supply,
+		&nft.Collection,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nft, nil
+}
+
+// InsertNFT 新增商品並寫入異動紀錄；同合集內 id 已存在時回傳 repository.ErrDuplicateID
+func (m *PostgresDBRepo) InsertNFT(ctx context.Context, nft models.NFT, userID int) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `insert into nft (id, name, description, meta, image, demo, rarity, weight, supply, collection)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			on conflict (collection, id) do nothing`
+
+	res, err := tx.ExecContext(ctx, stmt, nft.ID, nft.Name, nft.Desc, nft.Meta, nft.Image, demoFlag(nft.Demo), nft.Rarity, nft.Weight, nft.Supply, nft.Collection)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return repository.ErrDuplicateID
+	}
+
+	if err := insertCatalogAudit(ctx, tx, nft.Collection, nft.ID, models.CatalogCreate, userID, nil, &nft); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateNFT 更新商品並寫入異動紀錄（含修改前的內容）
+func (m *PostgresDBRepo) UpdateNFT(ctx context.Context, nft models.NFT, userID int) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getNFT(ctx, tx, nft.Collection, nft.ID)
+	if err != nil {
+		return err
+	}
+
+	stmt := `update nft set name = $1, description = $2, meta = $3, image = $4, demo = $5,
+			rarity = $6, weight = $7, supply = $8 where collection = $9 and id = $10`
+
+	_, err = tx.ExecContext(ctx, stmt, nft.Name, nft.Desc, nft.Meta, nft.Image, demoFlag(nft.Demo), nft.Rarity, nft.Weight, nft.Supply, nft.Collection, nft.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertCatalogAudit(ctx, tx, nft.Collection, nft.ID, models.CatalogUpdate, userID, before, &nft); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteNFT 刪除商品並寫入異動紀錄（保留刪除前的內容）
+func (m *PostgresDBRepo) DeleteNFT(ctx context.Context, collection string, id int, userID int) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getNFT(ctx, tx, collection, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `delete from nft where collection = $1 and id = $2`, collection, id); err != nil {
+		return err
+	}
+
+	if err := insertCatalogAudit(ctx, tx, collection, id, models.CatalogDelete, userID, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CatalogAudit 查詢合集的異動紀錄；nftID < 0 代表全部（最近 200 筆）
+func (m *PostgresDBRepo) CatalogAudit(ctx context.Context, collection string, nftID int) ([]*models.CatalogAudit, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `select id, collection, nft_id, action, coalesce(user_id, 0), coalesce(before, ''), coalesce(after, ''), created_at
+			from catalog_audit
+			where collection = $1 and ($2 < 0 or nft_id = $2)
+			order by id desc
+			limit 200`
+
+	rows, err := m.DB.QueryContext(ctx, query, collection, nftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []*models.CatalogAudit
+
+	for rows.Next() {
+		var a models.CatalogAudit
+		err := rows.Scan(
+			&a.ID,
+			&a.Collection,
+			&a.NFTID,
+			&a.Action,
+			&a.UserID,
+			&a.Before,
+			&a.After,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, &a)
+	}
+
+	return logs, nil
+}
+
+func insertCatalogAudit(ctx context.Context, tx *sql.Tx, collection string, nftID int, action string, userID int, before, after *models.NFT) error {
+	var b, a sql.NullString
+	if before != nil {
+		j, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		b = sql.NullString{String: string(j), Valid: true}
+	}
+	if after != nil {
+		j, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+		a = sql.NullString{String: string(j), Valid: true}
+	}
+
+	stmt := `insert into catalog_audit (collection, nft_id, action, user_id, before, after, created_at)
+			values ($1, $2, $3, $4, $5, $6, now())`
+
+	_, err := tx.ExecContext(ctx, stmt, collection, nftID, action, userID, b, a)
+
+	return err
+}
+
+func demoFlag(demo bool) int {
+	if demo {
+		return 1
+	}
+	return 0
+}
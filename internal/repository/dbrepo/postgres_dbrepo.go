@@ -3,38 +3,88 @@ package dbrepo
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"runtime"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/wkchen007/nftweb-back/internal/metrics"
 	"github.com/wkchen007/nftweb-back/internal/models"
+	"github.com/wkchen007/nftweb-back/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type PostgresDBRepo struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Timeout time.Duration // 單次查詢的上限，0 時使用 dbTimeout
 }
 
 const dbTimeout = time.Second * 3
 
+// withTimeout 在呼叫端的 ctx（通常是 request context）上加上查詢上限，
+// client 斷線或服務關閉時查詢會一併取消；同時為該 repo 方法建立 span，cancel 時結束 span 並記錄耗時
+func (m *PostgresDBRepo) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = dbTimeout
+	}
+
+	query := repoMethod()
+	ctx, span := tracing.Start(ctx, "db."+query, trace.WithSpanKind(trace.SpanKindClient))
+	span.SetAttributes(attribute.String("db.system.name", "postgresql"), attribute.String("db.operation.name", query))
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	start := time.Now()
+	return ctx, func() {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			span.SetStatus(codes.Error, "query timeout")
+		}
+		cancel()
+		span.End()
+		metrics.DBQueryDuration.WithLabelValues(query).Observe(metrics.Since(start))
+	}
+}
+
+// repoMethod 呼叫堆疊中最近的 PostgresDBRepo 公開方法名稱（略過 queryDeliveries 等內部 helper）
+func repoMethod() string {
+	pcs := make([]uintptr, 8)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		name := frame.Function[strings.LastIndex(frame.Function, ".")+1:]
+		if name != "" && unicode.IsUpper(rune(name[0])) {
+			return name
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
 func (m *PostgresDBRepo) Connection() *sql.DB {
 	return m.DB
 }
 
-func (m *PostgresDBRepo) AllNFTs() ([]*models.NFT, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+func (m *PostgresDBRepo) AllNFTs(ctx context.Context, collection string) ([]*models.NFT, error) {
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
 	query := `
 		select
-			id, name, description, meta, image
+			id, name, description, meta, image, demo = 1, rarity, collection
 		from
 			nft
-		where demo = '1'
+		where demo = '1' and collection = $1
 		order by
 			id
 	`
 
-	rows, err := m.DB.QueryContext(ctx, query)
+	rows, err := m.DB.QueryContext(ctx, query, collection)
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +100,9 @@ func (m *PostgresDBRepo) AllNFTs() ([]*models.NFT, error) {
 			&nft.Desc,
 			&nft.Meta,
 			&nft.Image,
+			&nft.Demo,
+			&nft.Rarity,
+			&nft.Collection,
 		)
 		if err != nil {
 			return nil, err
@@ -61,21 +114,22 @@ func (m *PostgresDBRepo) AllNFTs() ([]*models.NFT, error) {
 	return nfts, nil
 }
 
-func (m *PostgresDBRepo) GetTokenItem(ids []int) ([]models.TokenItem, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+func (m *PostgresDBRepo) GetTokenItem(ctx context.Context, collection string, ids []int) ([]models.TokenItem, error) {
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
 	placeholders := make([]string, len(ids))
-	args := make([]interface{}, len(ids))
+	args := make([]interface{}, len(ids)+1)
+	args[0] = collection
 	for i, id := range ids {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-		args[i] = id
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args[i+1] = id
 	}
 
 	query := fmt.Sprintf(`
 		select id, meta, image
 		from nft
-		where id in (%s)
+		where collection = $1 and id in (%s)
 	`, strings.Join(placeholders, ","))
 
 	rows, err := m.DB.QueryContext(ctx, query, args...)
@@ -103,19 +157,19 @@ func (m *PostgresDBRepo) GetTokenItem(ids []int) ([]models.TokenItem, error) {
 	return tokens, nil
 }
 
-func (m *PostgresDBRepo) GetBoxItem() (models.TokenItem, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+func (m *PostgresDBRepo) GetBoxItem(ctx context.Context, collection string) (models.TokenItem, error) {
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
 	query := `
 		select id, meta, image
 		from nft
-		where demo = '0'
+		where demo = '0' and collection = $1
 		limit 1
 	`
 
 	var token models.TokenItem
-	row := m.DB.QueryRowContext(ctx, query)
+	row := m.DB.QueryRowContext(ctx, query, collection)
 	err := row.Scan(
 		&token.TokenID,
 		&token.TokenURI,
@@ -128,16 +182,11 @@ func (m *PostgresDBRepo) GetBoxItem() (models.TokenItem, error) {
 	return token, nil
 }
 
-func (m *PostgresDBRepo) GetUserByEmail(email string) (*models.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
-	defer cancel()
-
-	query := `select id, email, first_name, last_name, password,wallet_address,
-			created_at, updated_at from users where email = $1`
+const userColumns = `id, email, first_name, last_name, password, wallet_address, role,
+			disabled, locked_until, created_at, updated_at`
 
+func scanUser(row *sql.Row) (*models.User, error) {
 	var user models.User
-	row := m.DB.QueryRowContext(ctx, query, email)
-
 	err := row.Scan(
 		&user.ID,
 		&user.Email,
@@ -145,6 +194,9 @@ func (m *PostgresDBRepo) GetUserByEmail(email string) (*models.User, error) {
 		&user.LastName,
 		&user.Password,
 		&user.WalletAddress,
+		&user.Role,
+		&user.Disabled,
+		&user.LockedUntil,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -155,3 +207,31 @@ func (m *PostgresDBRepo) GetUserByEmail(email string) (*models.User, error) {
 
 	return &user, nil
 }
+
+func (m *PostgresDBRepo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `select ` + userColumns + ` from users where email = $1`
+
+	return scanUser(m.DB.QueryRowContext(ctx, query, email))
+}
+
+func (m *PostgresDBRepo) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `select ` + userColumns + ` from users where id = $1`
+
+	return scanUser(m.DB.QueryRowContext(ctx, query, id))
+}
+
+// LockUser 將帳號鎖定到 until
+func (m *PostgresDBRepo) LockUser(ctx context.Context, id int, until time.Time) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	stmt := `update users set locked_until = $1, updated_at = now() where id = $2`
+	_, err := m.DB.ExecContext(ctx, stmt, until, id)
+	return err
+}
@@ -0,0 +1,138 @@
+package dbrepo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/wkchen007/nftweb-back/internal/models"
+)
+
+// InsertHolderSnapshot 在同一個交易內寫入快照與每個持有者
+func (m *PostgresDBRepo) InsertHolderSnapshot(ctx context.Context, snap models.HolderSnapshot) (int, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `insert into holder_snapshots (contract, block_number, source, created_at)
+			values ($1, $2, $3, now()) returning id`
+
+	var id int
+	err = tx.QueryRowContext(ctx, stmt, snap.Contract, snap.BlockNumber, snap.Source).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, h := range snap.Holders {
+		_, err := tx.ExecContext(ctx,
+			`insert into holder_snapshot_entries (snapshot_id, address, token_ids, count) values ($1, $2, $3, $4)`,
+			id,
+			h.Address,
+			strings.Join(h.TokenIDs, ","),
+			h.Count,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (m *PostgresDBRepo) GetHolderSnapshot(ctx context.Context, id int) (*models.HolderSnapshot, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `select id, contract, block_number, source, created_at from holder_snapshots where id = $1`
+
+	var snap models.HolderSnapshot
+	row := m.DB.QueryRowContext(ctx, query, id)
+	err := row.Scan(
+		&snap.ID,
+		&snap.Contract,
+		&snap.BlockNumber,
+		&snap.Source,
+		&snap.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `select address, token_ids, count from holder_snapshot_entries
+			where snapshot_id = $1 order by count desc, address`
+
+	rows, err := m.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h models.SnapshotHolder
+		var ids string
+		err := rows.Scan(
+			&h.Address,
+			&ids,
+			&h.Count,
+		)
+		if err != nil {
+			return nil, err
+		}
+		h.TokenIDs = strings.Split(ids, ",")
+
+		snap.Holders = append(snap.Holders, h)
+	}
+
+	return &snap, nil
+}
+
+// AllHolderSnapshots 列出合約的快照，新的在前
+func (m *PostgresDBRepo) AllHolderSnapshots(ctx context.Context, contract string) ([]*models.HolderSnapshot, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		select
+			id, contract, block_number, source, created_at
+		from
+			holder_snapshots
+		where
+			lower(contract) = lower($1)
+		order by
+			id desc
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, contract)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snaps []*models.HolderSnapshot
+
+	for rows.Next() {
+		var snap models.HolderSnapshot
+		err := rows.Scan(
+			&snap.ID,
+			&snap.Contract,
+			&snap.BlockNumber,
+			&snap.Source,
+			&snap.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		snaps = append(snaps, &snap)
+	}
+
+	return snaps, nil
+}
@@ -0,0 +1,367 @@
+package dbrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/wkchen007/nftweb-back/internal/models"
+)
+
+func (m *PostgresDBRepo) AllWebhookSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		select
+			id, url, secret, event_types, active, start_blocks, created_at, updated_at
+		from
+			webhook_subscriptions
+		order by
+			id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*models.WebhookSubscription
+
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		var types, blocks string
+		err := rows.Scan(
+			&sub.ID,
+			&sub.URL,
+			&sub.Secret,
+			&types,
+			&sub.Active,
+			&blocks,
+			&sub.CreatedAt,
+			&sub.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sub.EventTypes = models.SplitEventTypes(types)
+		if err := json.Unmarshal([]byte(blocks), &sub.StartBlocks); err != nil {
+			return nil, err
+		}
+
+		subs = append(subs, &sub)
+	}
+
+	return subs, nil
+}
+
+func (m *PostgresDBRepo) GetWebhookSubscription(ctx context.Context, id int) (*models.WebhookSubscription, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `select id, url, secret, event_types, active, start_blocks, created_at, updated_at
+			from webhook_subscriptions where id = $1`
+
+	var sub models.WebhookSubscription
+	var types, blocks string
+	row := m.DB.QueryRowContext(ctx, query, id)
+	err := row.Scan(
+		&sub.ID,
+		&sub.URL,
+		&sub.Secret,
+		&types,
+		&sub.Active,
+		&blocks,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	sub.EventTypes = models.SplitEventTypes(types)
+	if err := json.Unmarshal([]byte(blocks), &sub.StartBlocks); err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+func (m *PostgresDBRepo) InsertWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (int, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	blocks, err := json.Marshal(sub.StartBlocks)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := `insert into webhook_subscriptions (url, secret, event_types, active, start_blocks, created_at, updated_at)
+			values ($1, $2, $3, $4, $5, now(), now()) returning id`
+
+	var id int
+	err = m.DB.QueryRowContext(ctx, stmt,
+		sub.URL,
+		sub.Secret,
+		models.JoinEventTypes(sub.EventTypes),
+		sub.Active,
+		string(blocks),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (m *PostgresDBRepo) DeleteWebhookSubscription(ctx context.Context, id int) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	res, err := m.DB.ExecContext(ctx, `delete from webhook_subscriptions where id = $1`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// InsertWebhookDelivery 同一訂閱者已有相同 event_id 時不重複建立，回傳 id 0
+func (m *PostgresDBRepo) InsertWebhookDelivery(ctx context.Context, d models.WebhookDelivery) (int, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	stmt := `insert into webhook_deliveries
+			(subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at)
+			values ($1, $2, $3, $4, $5, 0, now(), now(), now())
+			on conflict (subscription_id, event_id) do nothing
+			returning id`
+
+	var id int
+	err := m.DB.QueryRowContext(ctx, stmt,
+		d.SubscriptionID,
+		d.EventID,
+		d.EventType,
+		d.Payload,
+		models.DeliveryPending,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+const deliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts,
+			next_attempt_at, last_error, created_at, updated_at`
+
+func scanDelivery(scan func(dest ...interface{}) error) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var lastError sql.NullString
+	err := scan(
+		&d.ID,
+		&d.SubscriptionID,
+		&d.EventID,
+		&d.EventType,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&lastError,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.LastError = lastError.String
+
+	return &d, nil
+}
+
+func (m *PostgresDBRepo) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+
+	for rows.Next() {
+		d, err := scanDelivery(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+func (m *PostgresDBRepo) GetWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `select ` + deliveryColumns + ` from webhook_deliveries where id = $1`
+
+	return scanDelivery(m.DB.QueryRowContext(ctx, query, id).Scan)
+}
+
+func (m *PostgresDBRepo) WebhookDeliveries(ctx context.Context, subscriptionID int) ([]*models.WebhookDelivery, error) {
+	query := `select ` + deliveryColumns + ` from webhook_deliveries
+			where subscription_id = $1 order by id desc limit 100`
+
+	return m.queryDeliveries(ctx, query, subscriptionID)
+}
+
+// ClaimWebhookDeliveries 取出待投遞且已到重試時間的投遞，並把 next_attempt_at 與 leased_until 延後 lease；
+// 多個 worker 同時取時以 skip locked 各拿不同的列，worker 中途停掉的投遞在 lease 過後會再被取出
+func (m *PostgresDBRepo) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	query := `update webhook_deliveries
+			set next_attempt_at = now() + $3 * interval '1 millisecond',
+				leased_until = now() + $3 * interval '1 millisecond', updated_at = now()
+			where id in (
+				select id from webhook_deliveries
+				where status = $1 and next_attempt_at <= now()
+				order by next_attempt_at
+				limit $2
+				for update skip locked
+			)
+			returning ` + deliveryColumns
+
+	return m.queryDeliveries(ctx, query, models.DeliveryPending, limit, lease.Milliseconds())
+}
+
+// ReplayWebhookDelivery 將投遞重設為待送出、重試次數歸零；正在投遞中（lease 未過期）的不重設，回傳 sql.ErrNoRows
+func (m *PostgresDBRepo) ReplayWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `update webhook_deliveries set status = $1, attempts = 0, last_error = '',
+			next_attempt_at = now(), leased_until = null, updated_at = now()
+			where id = $2 and (leased_until is null or leased_until <= now())
+			returning ` + deliveryColumns
+
+	return scanDelivery(m.DB.QueryRowContext(ctx, query, models.DeliveryPending, id).Scan)
+}
+
+// UpdateWebhookDelivery 寫入一次投遞的結果並釋放 lease
+func (m *PostgresDBRepo) UpdateWebhookDelivery(ctx context.Context, d models.WebhookDelivery) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	stmt := `update webhook_deliveries set status = $1, attempts = $2, next_attempt_at = $3,
+			last_error = $4, leased_until = null, updated_at = now() where id = $5`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		d.Status,
+		d.Attempts,
+		d.NextAttemptAt,
+		d.LastError,
+		d.ID,
+	)
+
+	return err
+}
+
+func (m *PostgresDBRepo) InsertWebhookAttempt(ctx context.Context, a models.WebhookAttempt) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	stmt := `insert into webhook_attempts (delivery_id, attempt, status_code, error, duration_ms, created_at)
+			values ($1, $2, $3, $4, $5, now())`
+
+	_, err := m.DB.ExecContext(ctx, stmt,
+		a.DeliveryID,
+		a.Attempt,
+		a.StatusCode,
+		a.Error,
+		a.DurationMs,
+	)
+
+	return err
+}
+
+func (m *PostgresDBRepo) WebhookAttempts(ctx context.Context, deliveryID int) ([]*models.WebhookAttempt, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	query := `select id, delivery_id, attempt, coalesce(status_code, 0), coalesce(error, ''),
+			coalesce(duration_ms, 0), created_at
+			from webhook_attempts where delivery_id = $1 order by attempt`
+
+	rows, err := m.DB.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*models.WebhookAttempt
+
+	for rows.Next() {
+		var a models.WebhookAttempt
+		err := rows.Scan(
+			&a.ID,
+			&a.DeliveryID,
+			&a.Attempt,
+			&a.StatusCode,
+			&a.Error,
+			&a.DurationMs,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		attempts = append(attempts, &a)
+	}
+
+	return attempts, nil
+}
+
+// GetEventCursor 合約事件下一次輪詢的起點；尚未記錄時回傳 sql.ErrNoRows
+func (m *PostgresDBRepo) GetEventCursor(ctx context.Context, chainID uint64, contract string) (uint64, error) {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var next int64
+	err := m.DB.QueryRowContext(ctx, `select next_block from event_cursors where chain_id = $1 and contract = $2`,
+		int64(chainID), contract).Scan(&next)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(next), nil
+}
+
+// SaveEventCursor 只會往前推進，避免 reload 時舊的輪詢覆寫較新的進度
+func (m *PostgresDBRepo) SaveEventCursor(ctx context.Context, chainID uint64, contract string, next uint64) error {
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	stmt := `insert into event_cursors (chain_id, contract, next_block, updated_at)
+			values ($1, $2, $3, now())
+			on conflict (chain_id, contract) do update
+			set next_block = greatest(event_cursors.next_block, excluded.next_block), updated_at = now()`
+
+	_, err := m.DB.ExecContext(ctx, stmt, int64(chainID), contract, int64(next))
+
+	return err
+}
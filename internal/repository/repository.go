@@ -1,15 +1,62 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/wkchen007/nftweb-back/internal/models"
 )
 
+// ErrDuplicateID 新增商品時 token id 已存在
+var ErrDuplicateID = errors.New("token id already exists")
+
+// ErrLocked advisory lock 已被其他連線（例如另一台 API）持有
+var ErrLocked = errors.New("locked by another process")
+
 type DatabaseRepo interface {
 	Connection() *sql.DB
-	AllNFTs() ([]*models.NFT, error)
-	GetTokenItem(id []int) ([]models.TokenItem, error)
-	GetBoxItem() (models.TokenItem, error)
-	GetUserByEmail(email string) (*models.User, error)
+	AllNFTs(ctx context.Context, collection string) ([]*models.NFT, error)
+	CatalogItems(ctx context.Context, collection string) ([]*models.NFT, error)
+	GetNFT(ctx context.Context, collection string, id int) (*models.NFT, error)
+	InsertNFT(ctx context.Context, nft models.NFT, userID int) error
+	UpdateNFT(ctx context.Context, nft models.NFT, userID int) error
+	DeleteNFT(ctx context.Context, collection string, id int, userID int) error
+	CatalogAudit(ctx context.Context, collection string, nftID int) ([]*models.CatalogAudit, error)
+	GetTokenItem(ctx context.Context, collection string, id []int) ([]models.TokenItem, error)
+	GetBoxItem(ctx context.Context, collection string) (models.TokenItem, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	LockUser(ctx context.Context, id int, until time.Time) error
+
+	AllWebhookSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, id int) (*models.WebhookSubscription, error)
+	InsertWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (int, error)
+	DeleteWebhookSubscription(ctx context.Context, id int) error
+	InsertWebhookDelivery(ctx context.Context, d models.WebhookDelivery) (int, error)
+	GetWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error)
+	WebhookDeliveries(ctx context.Context, subscriptionID int) ([]*models.WebhookDelivery, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, d models.WebhookDelivery) error
+	InsertWebhookAttempt(ctx context.Context, a models.WebhookAttempt) error
+	WebhookAttempts(ctx context.Context, deliveryID int) ([]*models.WebhookAttempt, error)
+	GetEventCursor(ctx context.Context, chainID uint64, contract string) (uint64, error)
+	SaveEventCursor(ctx context.Context, chainID uint64, contract string, next uint64) error
+
+	InsertHolderSnapshot(ctx context.Context, snap models.HolderSnapshot) (int, error)
+	GetHolderSnapshot(ctx context.Context, id int) (*models.HolderSnapshot, error)
+	AllHolderSnapshots(ctx context.Context, contract string) ([]*models.HolderSnapshot, error)
+
+	InsertAirdrop(ctx context.Context, a models.Airdrop) (int, error)
+	GetAirdrop(ctx context.Context, id int) (*models.Airdrop, error)
+	AllAirdrops(ctx context.Context, contract string) ([]*models.Airdrop, error)
+	UpdateAirdropStatus(ctx context.Context, id int, status string) error
+	UpdateAirdropRow(ctx context.Context, row models.AirdropRow) error
+	TryLockAirdrop(ctx context.Context, id int) (unlock func(), err error)
+
+	InsertAssignment(ctx context.Context, a models.Assignment) (int, error)
+	GetAssignment(ctx context.Context, contract string) (*models.Assignment, error)
+	RevealAssignment(ctx context.Context, id int) error
 }
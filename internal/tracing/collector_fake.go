@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// CollectorStandIn 本機測試用的 OTLP/HTTP collector：接受 POST /v1/traces（protobuf），
+// 記下收到的 span 名稱並印出 log，不需要另外架 collector
+type CollectorStandIn struct {
+	mu    sync.Mutex
+	spans []string
+}
+
+func NewCollectorStandIn() *CollectorStandIn {
+	return &CollectorStandIn{}
+}
+
+// Spans 目前為止收到的 span 名稱
+func (c *CollectorStandIn) Spans() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.spans...)
+}
+
+func (c *CollectorStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(b, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, s := range ss.Spans {
+				c.spans = append(c.spans, s.Name)
+				slog.InfoContext(r.Context(), "[tracing] collector stand-in: span", "name", s.Name, "trace_id", hex.EncodeToString(s.TraceId))
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	resp, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(resp)
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "github.com/wkchen007/nftweb-back"
+
+// Config OTLP/HTTP 匯出設定；Endpoint 空白時不匯出（仍會傳遞 trace context）
+type Config struct {
+	Endpoint    string  `yaml:"endpoint"`    // collector 的 URL，例如 http://otel-collector:4318
+	ServiceName string  `yaml:"serviceName"` // resource 的 service.name
+	SampleRatio float64 `yaml:"sampleRatio"` // 0 ~ 1，上游已取樣的 trace 一律沿用上游決定
+}
+
+// Setup 設定全域的 TracerProvider 與 W3C trace context 傳遞；回傳的 shutdown 會送出尚未匯出的 span
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.Endpoint == "" {
+		slog.InfoContext(ctx, "[tracing] no otlp endpoint, spans are not exported")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("otlp exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	slog.InfoContext(ctx, "[tracing] exporting spans", "service", cfg.ServiceName, "sample_ratio", cfg.SampleRatio)
+
+	return tp.Shutdown, nil
+}
+
+// Start 以專案的 tracer 開始 span；Setup 之前也可以呼叫（屆時為 no-op）
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, opts...)
+}
+
+// End 記錄 err（若有）後結束 span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestSetupExportsToCollector(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"endpoint without path", ""},
+		{"endpoint with traces path", "/v1/traces"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			collector := NewCollectorStandIn()
+			srv := httptest.NewServer(collector)
+			defer srv.Close()
+
+			shutdown, err := Setup(ctx, Config{Endpoint: srv.URL + tt.path, ServiceName: "test", SampleRatio: 1})
+			if err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+			_, span := Start(ctx, "test span")
+			End(span, nil)
+			if err := shutdown(ctx); err != nil {
+				t.Fatalf("shutdown: %v", err)
+			}
+
+			if spans := collector.Spans(); !slices.Contains(spans, "test span") {
+				t.Errorf("collector spans = %v, want test span", spans)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wkchen007/nftweb-back/internal/models"
+	"github.com/wkchen007/nftweb-back/internal/repository"
+)
+
+const (
+	maxAttempts  = 8
+	baseBackoff  = 10 * time.Second
+	maxBackoff   = time.Hour
+	pollInterval = 2 * time.Second
+	batchSize    = 20
+	leaseTime    = time.Minute // 取出的投遞在這段時間內不會被其他 worker 取走
+)
+
+// ErrDeliveryInFlight 投遞正由 worker 送出中，不能 replay
+var ErrDeliveryInFlight = errors.New("delivery is being sent, retry later")
+
+// Envelope 投遞給訂閱者的 JSON 內容
+type Envelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// HeadsFunc 回傳各鏈（chain id）目前的區塊高度，建立訂閱時記錄
+type HeadsFunc func(ctx context.Context) (map[uint64]uint64, error)
+
+// Dispatcher 將事件寫成投遞紀錄，並由背景 worker 以 HMAC-SHA256 簽名送出、失敗時指數退避重試
+type Dispatcher struct {
+	DB     repository.DatabaseRepo
+	heads  HeadsFunc
+	client *http.Client
+}
+
+func NewDispatcher(db repository.DatabaseRepo, heads HeadsFunc) *Dispatcher {
+	return &Dispatcher{
+		DB:     db,
+		heads:  heads,
+		client: newClient(10 * time.Second),
+	}
+}
+
+// Publish 為每個訂閱此事件類型的訂閱者建立一筆投遞；eventID 相同的事件只會投遞一次。
+// src 為鏈上事件的區塊，發生在訂閱建立之前的不投遞；非鏈上事件傳 nil
+func (d *Dispatcher) Publish(ctx context.Context, eventType, eventID string, src *models.EventSource, data interface{}) error {
+	subs, err := d.DB.AllWebhookSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("load subscriptions: %w", err)
+	}
+
+	payload, err := json.Marshal(Envelope{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if !sub.Active || !sub.Accepts(eventType) || !sub.Covers(src) {
+			continue
+		}
+		id, err := d.DB.InsertWebhookDelivery(ctx, models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        string(payload),
+		})
+		if err != nil {
+			return fmt.Errorf("insert delivery: %w", err)
+		}
+		if id != 0 {
+			slog.InfoContext(ctx, "[webhook] delivery queued", "event", eventType, "event_id", eventID, "subscription_id", sub.ID)
+		}
+	}
+	return nil
+}
+
+// Run 定期取出到期的投遞並送出，直到 ctx 結束
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		due, err := d.DB.ClaimWebhookDeliveries(ctx, batchSize, leaseTime)
+		if err != nil {
+			slog.ErrorContext(ctx, "[webhook] claim due deliveries", "err", err)
+			continue
+		}
+		for _, delivery := range due {
+			if err := d.deliver(ctx, delivery); err != nil {
+				slog.ErrorContext(ctx, "[webhook] delivery failed", "delivery_id", delivery.ID, "err", err)
+			}
+		}
+	}
+}
+
+// Replay 將投遞重設為待送出並重新計算重試次數（保留先前的嘗試紀錄）；
+// worker 正在送出的投遞回傳 ErrDeliveryInFlight，避免同一筆被送兩次
+func (d *Dispatcher) Replay(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	delivery, err := d.DB.ReplayWebhookDelivery(ctx, id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return delivery, err
+	}
+	// 沒有更新到：投遞不存在（回傳 sql.ErrNoRows）或正在送出
+	if _, err := d.DB.GetWebhookDelivery(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrDeliveryInFlight
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	sub, err := d.DB.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return fmt.Errorf("load subscription: %w", err)
+	}
+	if !sub.Active {
+		// 訂閱停用後不再送出，保留紀錄；重新啟用後可用 replay 重送
+		delivery.Status = models.DeliveryCanceled
+		delivery.LastError = "subscription inactive"
+		if err := d.DB.UpdateWebhookDelivery(ctx, *delivery); err != nil {
+			return fmt.Errorf("update delivery: %w", err)
+		}
+		slog.InfoContext(ctx, "[webhook] delivery canceled, subscription inactive", "delivery_id", delivery.ID, "subscription_id", sub.ID)
+		return nil
+	}
+
+	delivery.Attempts++
+	start := time.Now()
+	status, sendErr := d.send(ctx, sub, delivery)
+	attempt := models.WebhookAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+		StatusCode: status,
+		DurationMs: int(time.Since(start).Milliseconds()),
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+	case delivery.Attempts >= maxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = sendErr.Error()
+		attempt.Error = sendErr.Error()
+	default:
+		delivery.NextAttemptAt = time.Now().UTC().Add(backoff(delivery.Attempts))
+		delivery.LastError = sendErr.Error()
+		attempt.Error = sendErr.Error()
+	}
+
+	if err := d.DB.InsertWebhookAttempt(ctx, attempt); err != nil {
+		slog.ErrorContext(ctx, "[webhook] record attempt", "delivery_id", delivery.ID, "err", err)
+	}
+	if err := d.DB.UpdateWebhookDelivery(ctx, *delivery); err != nil {
+		return fmt.Errorf("update delivery: %w", err)
+	}
+	slog.InfoContext(ctx, "[webhook] delivery attempt", "delivery_id", delivery.ID, "attempt", delivery.Attempts, "url", sub.URL, "status", delivery.Status)
+	return nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(sub.Secret, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign 計算 HMAC-SHA256(secret, timestamp + "." + body)，訂閱者以相同方式驗證
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff 第 n 次失敗後的等待時間：10s, 20s, 40s ... 最多 1h
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff << (attempts - 1)
+	if wait <= 0 || wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
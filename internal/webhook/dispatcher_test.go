@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		// 以外部工具算出的固定值，簽名內容為 timestamp + "." + body
+		{"known vector", "s3cret", "1700000000", `{"type":"nft.minted"}`, "27ef342d72e3dfb04eb9c11c1d856e27b38e90e851e2cf56e15e63c4c41f49fa"},
+		{"empty body", "s3cret", "1700000000", "", hmacHex("s3cret", "1700000000.")},
+		{"binary body", "s3cret", "1700000000", "\x00\xff", hmacHex("s3cret", "1700000000.\x00\xff")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Fatalf("Sign = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// 時間戳或 secret 不同，簽名必須不同
+	base := Sign("s3cret", "1700000000", []byte("{}"))
+	if Sign("s3cret", "1700000001", []byte("{}")) == base {
+		t.Fatal("signature does not depend on timestamp")
+	}
+	if Sign("other", "1700000000", []byte("{}")) == base {
+		t.Fatal("signature does not depend on secret")
+	}
+}
+
+func hmacHex(secret, msg string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(msg))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// cgnat 電信業者級 NAT 共享位址（RFC 6598），部分雲端用於內部服務
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// blockedAddr 不允許投遞的位址：loopback、私有網段、CGNAT、link-local（含雲端 metadata 169.254.169.254）等
+func blockedAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || cgnat.Contains(ip) || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// checkURL 建立訂閱時解析 host，任何一個位址落在 blockedAddr 即拒絕
+func checkURL(ctx context.Context, u *url.URL) error {
+	host := u.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if blockedAddr(ip) {
+			return fmt.Errorf("address %s is not allowed", ip)
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for _, ip := range addrs {
+		if blockedAddr(ip) {
+			return fmt.Errorf("%s resolves to %s, which is not allowed", host, ip.Unmap())
+		}
+	}
+	return nil
+}
+
+// dialControl 連線前再檢查一次實際連線的位址，避免 DNS rebinding 或 redirect 到內網
+func dialControl(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if blockedAddr(ap.Addr()) {
+		return fmt.Errorf("dial %s: address is not allowed", address)
+	}
+	return nil
+}
+
+// newClient 投遞用的 http.Client，只會連到公開位址
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: dialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
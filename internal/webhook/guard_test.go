@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestBlockedAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fc00::1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"224.0.0.1", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:100.100.100.200", true},
+		{"100.63.255.255", false},
+		{"100.128.0.1", false},
+		{"8.8.8.8", false},
+		{"2606:4700:4700::1111", false},
+		{"::ffff:8.8.8.8", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := blockedAddr(netip.MustParseAddr(tt.addr)); got != tt.blocked {
+				t.Fatalf("blockedAddr(%s) = %v, want %v", tt.addr, got, tt.blocked)
+			}
+		})
+	}
+	if !blockedAddr(netip.Addr{}) {
+		t.Fatal("zero address must be blocked")
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://8.8.8.8/hook", false},
+		{"https://[2606:4700:4700::1111]:8443/hook", false},
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://100.64.1.1/hook", true},
+		{"http://[::ffff:10.0.0.1]/hook", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = checkURL(context.Background(), u)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkURL(%s) err = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"8.8.8.8:443", false},
+		{"127.0.0.1:80", true},
+		{"100.64.0.1:443", true},
+		{"[::1]:443", true},
+		{"not-an-address", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := dialControl("tcp", tt.address, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dialControl(%s) err = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
+	"github.com/wkchen007/nftweb-back/internal/models"
+)
+
+var knownEventTypes = map[string]bool{
+	"*":                         true,
+	models.EventNFTMinted:       true,
+	models.EventNFTTransferred:  true,
+	models.EventNFTApproval:     true,
+	models.EventWalletConfirmed: true,
+}
+
+type Handlers struct {
+	dispatcher *Dispatcher
+}
+
+func NewHandlers(d *Dispatcher) *Handlers {
+	return &Handlers{dispatcher: d}
+}
+
+type SubscribeRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Secret     string   `json:"secret,omitempty"` // 留空則自動產生
+}
+
+func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.dispatcher.DB.AllWebhookSubscriptions(r.Context())
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return
+	}
+	// 列表不回傳 secret
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+
+	h.writeJSON(w, http.StatusOK, subs)
+}
+
+func (h *Handlers) Subscribe(w http.ResponseWriter, r *http.Request) {
+	var req SubscribeRequest
+	err := h.readJSON(w, r, &req)
+	if err != nil {
+		httpapi.WriteError(w, r, err)
+		return
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid url"))
+		return
+	}
+	if err := checkURL(r.Context(), u); err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("url is not allowed: %s", err))
+		return
+	}
+	if len(req.EventTypes) == 0 {
+		req.EventTypes = []string{"*"}
+	}
+	for _, t := range req.EventTypes {
+		if !knownEventTypes[t] {
+			httpapi.WriteError(w, r, httpapi.Invalid("unknown event type: %s", t))
+			return
+		}
+	}
+	if req.Secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			httpapi.WriteError(w, r, httpapi.Internal(err))
+			return
+		}
+		req.Secret = hex.EncodeToString(b)
+	}
+
+	// 記錄各鏈目前的高度，之後重播舊區塊時不會投遞給新訂閱者
+	heads, err := h.dispatcher.heads(r.Context())
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Upstream("get block number failed", err))
+		return
+	}
+
+	sub := models.WebhookSubscription{
+		URL:         u.String(),
+		Secret:      req.Secret,
+		EventTypes:  req.EventTypes,
+		Active:      true,
+		StartBlocks: heads,
+	}
+	sub.ID, err = h.dispatcher.DB.InsertWebhookSubscription(r.Context(), sub)
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return
+	}
+	slog.InfoContext(r.Context(), "[webhook] subscription created", "id", sub.ID, "url", sub.URL, "event_types", sub.EventTypes)
+
+	// 只有建立時回傳 secret，供訂閱者驗證簽名
+	h.writeJSON(w, http.StatusCreated, sub)
+}
+
+func (h *Handlers) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid id"))
+		return
+	}
+
+	if err := h.dispatcher.DB.DeleteWebhookSubscription(r.Context(), id); err != nil {
+		h.notFoundOr(w, r, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, JSONResponse{Message: fmt.Sprintf("subscription %d deleted", id)})
+}
+
+func (h *Handlers) Deliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid id"))
+		return
+	}
+
+	deliveries, err := h.dispatcher.DB.WebhookDeliveries(r.Context(), id)
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, deliveries)
+}
+
+func (h *Handlers) Attempts(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid id"))
+		return
+	}
+
+	attempts, err := h.dispatcher.DB.WebhookAttempts(r.Context(), id)
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Internal(err))
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, attempts)
+}
+
+func (h *Handlers) Replay(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpapi.WriteError(w, r, httpapi.Invalid("invalid id"))
+		return
+	}
+
+	delivery, err := h.dispatcher.Replay(r.Context(), id)
+	if errors.Is(err, ErrDeliveryInFlight) {
+		httpapi.WriteError(w, r, httpapi.Conflict("%s", err))
+		return
+	}
+	if err != nil {
+		h.notFoundOr(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "[webhook] delivery queued for replay", "id", id)
+
+	h.writeJSON(w, http.StatusAccepted, delivery)
+}
+
+func (h *Handlers) notFoundOr(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.WriteError(w, r, httpapi.NotFound("not found"))
+		return
+	}
+	httpapi.WriteError(w, r, httpapi.Internal(err))
+}
@@ -0,0 +1,17 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/wkchen007/nftweb-back/internal/httpapi"
+)
+
+type JSONResponse = httpapi.JSONResponse
+
+func (h *Handlers) writeJSON(w http.ResponseWriter, status int, data interface{}, headers ...http.Header) error {
+	return httpapi.WriteJSON(w, status, data, headers...)
+}
+
+func (h *Handlers) readJSON(w http.ResponseWriter, r *http.Request, data interface{}) error {
+	return httpapi.ReadJSON(w, r, data)
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+// Group 背景工作（事件輪詢、webhook 投遞、空投批次等）的生命週期；
+// 關閉服務時以 Stop 取消共用的 ctx 並等待所有工作結束
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+func NewGroup(parent context.Context) *Group {
+	ctx, cancel := context.WithCancel(parent)
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Context 所有背景工作共用的 ctx，Stop 時取消
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Go 在背景執行 fn；Stop 之後不再啟動新工作
+func (g *Group) Go(fn func(ctx context.Context)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn(g.ctx)
+	}()
+}
+
+// Stop 取消所有背景工作並等待結束，ctx 逾時則回傳 ctx 的錯誤
+func (g *Group) Stop(ctx context.Context) error {
+	g.mu.Lock()
+	g.stopped = true
+	g.mu.Unlock()
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
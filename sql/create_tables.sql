@@ -6,47 +6,76 @@ CREATE TABLE IF NOT EXISTS users (
     email VARCHAR(255) UNIQUE,
     password VARCHAR(255),
     wallet_address VARCHAR(50),
+    role VARCHAR(20) NOT NULL DEFAULT 'user',
+    disabled BOOLEAN NOT NULL DEFAULT false,
+    locked_until TIMESTAMP WITHOUT TIME ZONE,
     created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
     updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
 );
 
+-- 舊資料庫補欄位，需在插入資料前完成
+-- 角色：admin 才能異動商品目錄與執行管理功能（舊資料庫需自行將管理者設為 admin）
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
+-- 停用與登入失敗鎖定
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITHOUT TIME ZONE;
+
 -- 插入資料，如果 email 已存在則略過
-INSERT INTO users (first_name, last_name, email, password, wallet_address)
+INSERT INTO users (first_name, last_name, email, password, wallet_address, role)
 VALUES (
   'Peter',
   'Chen',
   'test@example.com',
   '$2a$14$wVsaPvJnJJsomWArouWCtusem6S/.Gauq/GjOIEHpyh2DAMmso1wy',
-  '0x1deAe8b25D834F31B88058bc137E8e80E54f1F86'
+  '0x1deAe8b25D834F31B88058bc137E8e80E54f1F86',
+  'admin'
 ),
 (
   'Andy',
   'Lin',
   'test2@example.com',
   '$2a$14$wVsaPvJnJJsomWArouWCtusem6S/.Gauq/GjOIEHpyh2DAMmso1wy',
-  '0xA278Aa560B6A1D2ED46F7faf724C67E9eF20B2EA'
+  '0xA278Aa560B6A1D2ED46F7faf724C67E9eF20B2EA',
+  'user'
 )
 ON CONFLICT (email) DO NOTHING;
 
 -- 建立 nft table (若不存在才建立)
 CREATE TABLE IF NOT EXISTS nft (
-    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    id INT GENERATED BY DEFAULT AS IDENTITY,
     name VARCHAR(50),
     description TEXT,
     meta VARCHAR(255),
     image VARCHAR(255),
-    demo INT
+    demo INT,
+    rarity VARCHAR(20) NOT NULL DEFAULT 'common',
+    weight INT NOT NULL DEFAULT 1,
+    supply INT NOT NULL DEFAULT 1,
+    collection VARCHAR(50) NOT NULL DEFAULT 'default',
+    PRIMARY KEY (collection, id)
 );
 
--- 插入資料
-INSERT INTO nft
+-- 稀有度：舊資料庫補欄位（需在插入資料前完成），既有商品的稀有度與權重由 catalog API 維護
+ALTER TABLE nft ADD COLUMN IF NOT EXISTS rarity VARCHAR(20) NOT NULL DEFAULT 'common';
+ALTER TABLE nft ADD COLUMN IF NOT EXISTS weight INT NOT NULL DEFAULT 1;
+ALTER TABLE nft ADD COLUMN IF NOT EXISTS supply INT NOT NULL DEFAULT 1;
+
+-- 多合集：舊資料庫補 collection 欄位，主鍵改為 (collection, id)
+ALTER TABLE nft ADD COLUMN IF NOT EXISTS collection VARCHAR(50) NOT NULL DEFAULT 'default';
+ALTER TABLE nft DROP CONSTRAINT IF EXISTS nft_pkey;
+ALTER TABLE nft ADD PRIMARY KEY (collection, id);
+
+-- 插入資料（稀有度與權重隨商品資料一起給定，隱藏版權重較低）
+INSERT INTO nft (id, name, description, meta, image, demo, rarity, weight)
 VALUES (
   '0',
   '白鳥愛羅',
   '神越高中二年D班的人氣美少女，長著一頭粉髮以及可愛的外表。',
   'bafybeiectyzmhyq5mjqvdbk3x2g77zbs7odwdkfqzuyzfpey3bxweirasu/0.json',
   'bafybeicqewn5j2amvj6hskp3mey6uztb44gmfoyyk6qsl2wq3zqwydfzdu/0.jpg',
-  '1'
+  '1',
+  'common',
+  10
 ),
 (
   '1',
@@ -54,7 +83,9 @@ VALUES (
   '出生於靈媒師家庭的辣妹高中生，暱稱「小桃」，自信、膽大、頑強的超能力少女，就讀於神越高中二年B班。',
   'bafybeiectyzmhyq5mjqvdbk3x2g77zbs7odwdkfqzuyzfpey3bxweirasu/1.json',
   'bafybeicqewn5j2amvj6hskp3mey6uztb44gmfoyyk6qsl2wq3zqwydfzdu/1.jpg',
-  '1'
+  '1',
+  'common',
+  10
 ),
 (
   '2',
@@ -62,7 +93,9 @@ VALUES (
   '超自然御宅族，以「鄙人」為第一人稱，就讀於神越高中二年C班。',
   'bafybeiectyzmhyq5mjqvdbk3x2g77zbs7odwdkfqzuyzfpey3bxweirasu/2.json',
   'bafybeicqewn5j2amvj6hskp3mey6uztb44gmfoyyk6qsl2wq3zqwydfzdu/2.jpg',
-  '1'
+  '1',
+  'common',
+  10
 ),
 (
   '3',
@@ -70,7 +103,9 @@ VALUES (
   '神出鬼沒、在日本各地作亂的近代妖怪，也是厄卡倫最初遇到的妖怪，存在歷史久遠，曾被稱為「百公里婆婆」，對自己的腳程有絕對自信，若賽跑輸給她就會被詛咒。',
   'bafybeiectyzmhyq5mjqvdbk3x2g77zbs7odwdkfqzuyzfpey3bxweirasu/3.json',
   'bafybeicqewn5j2amvj6hskp3mey6uztb44gmfoyyk6qsl2wq3zqwydfzdu/3.jpg',
-  '1'
+  '1',
+  'common',
+  10
 ),
 (
   '4',
@@ -78,7 +113,9 @@ VALUES (
   '小桃的初戀，暱稱「寺仁」，轉學到小桃的高中並同班，身強體壯的賣萌男。',
   'bafybeiectyzmhyq5mjqvdbk3x2g77zbs7odwdkfqzuyzfpey3bxweirasu/4.json',
   'bafybeicqewn5j2amvj6hskp3mey6uztb44gmfoyyk6qsl2wq3zqwydfzdu/4.jpg',
-  '1'
+  '1',
+  'common',
+  10
 ),
 (
   '5',
@@ -86,7 +123,9 @@ VALUES (
   '最初試圖綁架小桃的外星人，來自賽伯星，擁有念動力與基因改造能力。種族只有雄性，需要靠複製技術增加個體，為了找回生殖機能而找人類女性下手。',
   'bafybeiectyzmhyq5mjqvdbk3x2g77zbs7odwdkfqzuyzfpey3bxweirasu/5.json',
   'bafybeicqewn5j2amvj6hskp3mey6uztb44gmfoyyk6qsl2wq3zqwydfzdu/5.jpg',
-  '1'
+  '1',
+  'common',
+  10
 ),
 (
   '6',
@@ -94,7 +133,9 @@ VALUES (
   '高倉健變身造型，可以使用高速婆婆的力量，進行戰鬥。',
   'bafybeiectyzmhyq5mjqvdbk3x2g77zbs7odwdkfqzuyzfpey3bxweirasu/6.json',
   'bafybeicqewn5j2amvj6hskp3mey6uztb44gmfoyyk6qsl2wq3zqwydfzdu/6.jpg',
-  '1'
+  '1',
+  'rare',
+  5
 ),
 (
   '7',
@@ -102,7 +143,9 @@ VALUES (
   '擅長結界術的靈媒師，小桃的奶奶，人稱「多多利亞三太」，年輕的外表與實際年齡不符。',
   'bafybeiectyzmhyq5mjqvdbk3x2g77zbs7odwdkfqzuyzfpey3bxweirasu/7.json',
   'bafybeicqewn5j2amvj6hskp3mey6uztb44gmfoyyk6qsl2wq3zqwydfzdu/7.jpg',
-  '1'
+  '1',
+  'common',
+  10
 ),
 (
   '8',
@@ -110,7 +153,9 @@ VALUES (
   '高速婆婆盲盒隱藏版，隨機出現。',
   'bafybeiectyzmhyq5mjqvdbk3x2g77zbs7odwdkfqzuyzfpey3bxweirasu/8.json',
   'bafybeicqewn5j2amvj6hskp3mey6uztb44gmfoyyk6qsl2wq3zqwydfzdu/8.jpg',
-  '1'
+  '1',
+  'secret',
+  1
 ),
 (
   '9',
@@ -118,5 +163,131 @@ VALUES (
   '未開箱前的圖案。',
   'bafkreicnovsrbhko6exqtctuhqyg6nvloulmydgu4onfzpp4uqkm7hxle4',
   'bafkreiek5l646yuqixcqxaeengimuub2jcd3zqywvlty2725ecgyjntq44',
-  '0'
-)
\ No newline at end of file
+  '0',
+  'common',
+  1
+);
+
+-- 建立 webhook 訂閱者 table（event_types 以逗號分隔，* 代表全部）
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    url VARCHAR(500) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types VARCHAR(255) NOT NULL DEFAULT '*',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    start_blocks TEXT NOT NULL DEFAULT '{}', -- 訂閱建立時各鏈的區塊高度（JSON，chain id → block），更早的鏈上事件不投遞
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+-- 建立 webhook 投遞 table（同一訂閱者同一事件只投遞一次）
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    subscription_id INT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    event_id VARCHAR(100) NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    leased_until TIMESTAMP WITHOUT TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (status, next_attempt_at);
+
+-- 建立合約事件輪詢進度 table（重啟後從 next_block 繼續）
+CREATE TABLE IF NOT EXISTS event_cursors (
+    chain_id BIGINT NOT NULL,
+    contract VARCHAR(50) NOT NULL,
+    next_block BIGINT NOT NULL,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (chain_id, contract)
+);
+
+-- 建立 webhook 投遞紀錄 table（每次嘗試一筆）
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    delivery_id INT NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt INT NOT NULL,
+    status_code INT,
+    error TEXT,
+    duration_ms INT,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+-- 建立持有者快照 table（某區塊高度時每個地址持有的 token）
+CREATE TABLE IF NOT EXISTS holder_snapshots (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    contract VARCHAR(50) NOT NULL,
+    block_number BIGINT NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS holder_snapshots_contract_idx ON holder_snapshots (lower(contract));
+
+CREATE TABLE IF NOT EXISTS holder_snapshot_entries (
+    snapshot_id INT NOT NULL REFERENCES holder_snapshots(id) ON DELETE CASCADE,
+    address VARCHAR(50) NOT NULL,
+    token_ids TEXT NOT NULL,
+    count INT NOT NULL,
+    PRIMARY KEY (snapshot_id, address)
+);
+
+-- 建立空投批次 table（每列一個收件地址，可續傳）
+CREATE TABLE IF NOT EXISTS airdrops (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    contract VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    total_amount INT NOT NULL,
+    value_wei VARCHAR(80) NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS airdrops_contract_idx ON airdrops (lower(contract));
+
+CREATE TABLE IF NOT EXISTS airdrop_rows (
+    airdrop_id INT NOT NULL REFERENCES airdrops(id) ON DELETE CASCADE,
+    row_no INT NOT NULL,
+    address VARCHAR(50) NOT NULL,
+    amount INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    tx_hash VARCHAR(70),
+    error TEXT,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (airdrop_id, row_no)
+);
+
+-- 建立商品目錄異動紀錄 table（before / after 為 JSON）
+CREATE TABLE IF NOT EXISTS catalog_audit (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    collection VARCHAR(50) NOT NULL DEFAULT 'default',
+    nft_id INT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    user_id INT,
+    before TEXT,
+    after TEXT,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS catalog_audit_collection_nft_idx ON catalog_audit (collection, nft_id);
+
+-- 建立盲盒分配 table（commit-reveal：開盒前只公開 commitment）
+CREATE TABLE IF NOT EXISTS assignments (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    contract VARCHAR(50) NOT NULL,
+    commitment VARCHAR(70) NOT NULL,
+    seed VARCHAR(70) NOT NULL,
+    pool TEXT NOT NULL,
+    mapping TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    revealed_at TIMESTAMP WITHOUT TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS assignments_contract_idx ON assignments (contract);